@@ -0,0 +1,177 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/consumer"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/logging"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/service"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultDeadLetterListCount   = 100
+	defaultAnomalyEventListCount = 100
+)
+
+// AdminAuth rejects requests that don't carry the admin token as a bearer token
+func AdminAuth(token string, next http.HandlerFunc) http.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// DeadLetterListHandler returns the oldest dead-letter entries.
+// Usage: GET /admin/dlq?count=50
+func DeadLetterListHandler(deadLetterQueue consumer.DeadLetterQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		count := int64(defaultDeadLetterListCount)
+		if countStr := r.URL.Query().Get("count"); countStr != "" {
+			parsed, err := strconv.ParseInt(countStr, 10, 64)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "invalid count", http.StatusBadRequest)
+				return
+			}
+			count = parsed
+		}
+
+		entries, err := deadLetterQueue.List(r.Context(), count)
+		if err != nil {
+			logging.Error("Failed to list dead-letter entries", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, entries)
+	}
+}
+
+// DeadLetterRequeueHandler writes dead-letter entries back to the telemetry stream.
+// Usage: POST /admin/dlq/requeue?id=<dlq-id>&id=<dlq-id>
+func DeadLetterRequeueHandler(deadLetterQueue consumer.DeadLetterQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		ids := r.URL.Query()["id"]
+		if len(ids) == 0 {
+			http.Error(w, "at least one id is required", http.StatusBadRequest)
+			return
+		}
+
+		result := deadLetterQueue.Requeue(r.Context(), ids...)
+		if len(result.Failed) > 0 {
+			logging.Error("Failed to requeue dead-letter entries",
+				zap.Int("requeued", len(result.Requeued)),
+				zap.Any("failed", result.Failed),
+			)
+			// Some entries may have been requeued; report each one's outcome
+			writeJSON(w, http.StatusMultiStatus, result)
+			return
+		}
+
+		logging.Info("Dead-letter entries requeued", zap.Int("requeued", len(result.Requeued)))
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// AnomalyEventListHandler returns anomaly incidents, most severe first.
+// Usage: GET /admin/anomalies?status=open&count=50
+func AnomalyEventListHandler(anomalyEventService service.AnomalyEventService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		count := defaultAnomalyEventListCount
+		if countStr := r.URL.Query().Get("count"); countStr != "" {
+			parsed, err := strconv.Atoi(countStr)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "invalid count", http.StatusBadRequest)
+				return
+			}
+			count = parsed
+		}
+
+		status := model.AnomalyEventStatus(r.URL.Query().Get("status"))
+		switch status {
+		case "", model.AnomalyEventOpen, model.AnomalyEventAcknowledged, model.AnomalyEventResolved:
+		default:
+			http.Error(w, "invalid status", http.StatusBadRequest)
+			return
+		}
+
+		events, err := anomalyEventService.List(status, count)
+		if err != nil {
+			logging.Error("Failed to list anomaly events", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, events)
+	}
+}
+
+// AnomalyEventAcknowledgeHandler acknowledges an open anomaly incident.
+// Usage: POST /admin/anomalies/ack?id=<event-id>&by=<operator>
+func AnomalyEventAcknowledgeHandler(anomalyEventService service.AnomalyEventService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 64)
+		if err != nil || id == 0 {
+			http.Error(w, "invalid id", http.StatusBadRequest)
+			return
+		}
+
+		acknowledgedBy := r.URL.Query().Get("by")
+		if acknowledgedBy == "" {
+			http.Error(w, "by is required", http.StatusBadRequest)
+			return
+		}
+
+		event, err := anomalyEventService.Acknowledge(r.Context(), uint(id), acknowledgedBy)
+		if errors.Is(err, service.ErrAnomalyEventNotOpen) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logging.Error("Failed to acknowledge anomaly event", zap.Error(err), zap.Uint64("anomaly_event_id", id))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		logging.Info("Anomaly event acknowledged", zap.Uint64("anomaly_event_id", id), zap.String("by", acknowledgedBy))
+		writeJSON(w, http.StatusOK, event)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logging.Error("Failed to encode response", zap.Error(err))
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	redisv8 "github.com/go-redis/redis/v8"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/consumer"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/logging"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestMain sets up the logger once for every handler test
+func TestMain(m *testing.M) {
+	logging.CreateLogger(zapcore.ErrorLevel)
+	os.Exit(m.Run())
+}
+
+// stubDeadLetterQueue is a DeadLetterQueue whose Requeue fails for the configured IDs
+type stubDeadLetterQueue struct {
+	failed map[string]string
+}
+
+func (q *stubDeadLetterQueue) Send(context.Context, redisv8.XMessage, error, int64, string) error {
+	return nil
+}
+
+func (q *stubDeadLetterQueue) List(context.Context, int64) ([]*consumer.DeadLetterEntry, error) {
+	return nil, nil
+}
+
+func (q *stubDeadLetterQueue) Requeue(_ context.Context, ids ...string) *consumer.RequeueResult {
+	result := &consumer.RequeueResult{Requeued: []string{}}
+	for _, id := range ids {
+		if reason, ok := q.failed[id]; ok {
+			if result.Failed == nil {
+				result.Failed = make(map[string]string)
+			}
+			result.Failed[id] = reason
+			continue
+		}
+		result.Requeued = append(result.Requeued, id)
+	}
+	return result
+}
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"valid token", "Bearer secret", http.StatusOK},
+		{"missing header", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer guess", http.StatusUnauthorized},
+		{"token prefix", "Bearer secre", http.StatusUnauthorized},
+		{"token without scheme", "secret", http.StatusUnauthorized},
+		{"other scheme", "Basic secret", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := AdminAuth("secret", func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/dlq", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if called != (tt.want == http.StatusOK) {
+				t.Errorf("handler called = %v, want %v", called, tt.want == http.StatusOK)
+			}
+			if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("WWW-Authenticate = %q, want Bearer", rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestDeadLetterRequeueHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		query        string
+		failed       map[string]string
+		want         int
+		wantRequeued int
+		wantFailed   int
+	}{
+		{"all requeued", http.MethodPost, "?id=1-0&id=2-0", nil, http.StatusOK, 2, 0},
+		{"partial failure", http.MethodPost, "?id=1-0&id=2-0", map[string]string{"2-0": "dead-letter entry not found"}, http.StatusMultiStatus, 1, 1},
+		{"all failed", http.MethodPost, "?id=1-0", map[string]string{"1-0": "dead-letter entry not found"}, http.StatusMultiStatus, 0, 1},
+		{"no ids", http.MethodPost, "", nil, http.StatusBadRequest, 0, 0},
+		{"wrong method", http.MethodGet, "?id=1-0", nil, http.StatusMethodNotAllowed, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := DeadLetterRequeueHandler(&stubDeadLetterQueue{failed: tt.failed})
+
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest(tt.method, "/admin/dlq/requeue"+tt.query, nil))
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want != http.StatusOK && tt.want != http.StatusMultiStatus {
+				return
+			}
+
+			// Each entry's outcome is reported, so a partly applied request can be retried for the failed ones
+			var result consumer.RequeueResult
+			if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+				t.Fatalf("failed to decode response %q: %v", rec.Body.String(), err)
+			}
+			if len(result.Requeued) != tt.wantRequeued || len(result.Failed) != tt.wantFailed {
+				t.Errorf("result = %+v, want %d requeued and %d failed", result, tt.wantRequeued, tt.wantFailed)
+			}
+			for id, reason := range tt.failed {
+				if result.Failed[id] != reason {
+					t.Errorf("failure of %s = %q, want %q", id, result.Failed[id], reason)
+				}
+			}
+		})
+	}
+}
+
+func TestDeadLetterListHandlerRejectsInvalidCount(t *testing.T) {
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", http.StatusOK},
+		{"?count=10", http.StatusOK},
+		{"?count=0", http.StatusBadRequest},
+		{"?count=ten", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			DeadLetterListHandler(&stubDeadLetterQueue{})(rec, httptest.NewRequest(http.MethodGet, "/admin/dlq"+tt.query, nil))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Geofence schedules load IANA time zones; the runtime image has no zoneinfo
 
 	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/consumer"
 	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/config"
@@ -80,41 +81,169 @@ func main() {
 		}
 	}
 
+	// Schema migrations and TimescaleDB setup run regardless of auto_migrate
+	timescaleConfig := postgres.TimescaleConfig{
+		ChunkInterval:   time.Duration(cfg.TimescaleChunkHours) * time.Hour,
+		SpacePartitions: cfg.TimescalePartitions,
+		CompressAfter:   time.Duration(cfg.TimescaleCompressDays) * 24 * time.Hour,
+		RetainFor:       time.Duration(cfg.TimescaleRetainDays) * 24 * time.Hour,
+	}
+	if err := postgres.Migrate(db, timescaleConfig); err != nil {
+		logging.Fatal("Failed to run schema migrations", zap.Error(err))
+	}
+
 	// Initialize repositories
 	aircraftRepo := repository.NewAircraftRepository(db)
 	thresholdRepo := repository.NewThresholdRepository(db)
 	geofenceRepo := repository.NewGeofenceRepository(db)
-	telemetryRepo := repository.NewTelemetryRepository(db)
+	geofenceEventRepo := repository.NewGeofenceEventRepository(db)
+	quarantineRepo := repository.NewQuarantineRepository(db)
+	unknownAircraftRepo := repository.NewUnknownAircraftRepository(db)
+	anomalyEventRepo := repository.NewAnomalyEventRepository(db)
+
+	var telemetryRepo repository.TelemetryRepository
+	switch cfg.TelemetryWriteBackend {
+	case "copy":
+		telemetryRepo = repository.NewTelemetryCopyRepository(db)
+	case "", "gorm":
+		telemetryRepo = repository.NewTelemetryRepository(db)
+	default:
+		logging.Fatal("Unknown telemetry write backend", zap.String("backend", cfg.TelemetryWriteBackend))
+	}
 
 	// Initialize services
-	aircraftService := service.NewAircraftService(aircraftRepo)
-	thresholdService := service.NewThresholdService(thresholdRepo)
-	geofenceService := service.NewGeofenceService(geofenceRepo)
-	anomalyService := service.NewAnomalyService(thresholdService, geofenceService)
+	aircraftService := service.NewAircraftService(
+		aircraftRepo,
+		redisClient,
+		cfg.RedisPubSubControl,
+		time.Duration(cfg.AircraftCacheTTL)*time.Second,
+		time.Duration(cfg.AircraftCacheNegativeTTL)*time.Second,
+	)
+	go aircraftService.WatchChanges(ctx)
+	thresholdCache := service.NewThresholdCache(
+		thresholdRepo,
+		redisClient,
+		cfg.RedisPubSubControl,
+		time.Duration(cfg.ThresholdRefreshInterval)*time.Second,
+	)
+	if err := thresholdCache.Refresh(); err != nil {
+		logging.Fatal("Failed to load thresholds", zap.Error(err))
+	}
+	go thresholdCache.WatchChanges(ctx)
+	thresholdService := service.NewThresholdService(thresholdCache)
+	geofenceService := service.NewGeofenceService(geofenceRepo, time.Duration(cfg.GeofenceRefreshInterval)*time.Second)
+	if err := geofenceService.Refresh(); err != nil {
+		logging.Fatal("Failed to load geofences", zap.Error(err))
+	}
+	go geofenceService.WatchChanges(ctx)
+	anomalyService := service.NewAnomalyService(
+		thresholdService,
+		geofenceService,
+		time.Duration(cfg.GeofenceLookAhead)*time.Second,
+		cfg.GroundedMovementSpeed,
+	)
 
 	// Initialize consumer
-	streamKey := cfg.RedisStreamKey
-	consumerGroup := cfg.RedisConsumerGroup
-	consumerName := fmt.Sprintf("heisenberg-worker/%s", getHostname())
+	consumerConfig := consumer.Config{
+		StreamKey:        cfg.RedisStreamKey,
+		GroupName:        cfg.RedisConsumerGroup,
+		ConsumerName:     fmt.Sprintf("heisenberg-worker/%s", getHostname()),
+		ReclaimInterval:  time.Duration(cfg.RedisReclaimInterval) * time.Second,
+		ReclaimMinIdle:   time.Duration(cfg.RedisReclaimMinIdle) * time.Second,
+		ReclaimBatchSize: cfg.RedisReclaimBatchSize,
+		MaxDeliveries:    cfg.RedisMaxDeliveries,
+		WorkerLanes:      cfg.WorkerLanes,
+		LaneBufferSize:   cfg.WorkerLaneBufferSize,
+		MaxFutureSkew:    time.Duration(cfg.TelemetryMaxSkew) * time.Second,
+		MaxPastAge:       time.Duration(cfg.TelemetryMaxAge) * time.Second,
+	}
 
-	streamConsumer := consumer.NewStreamConsumer(redisClient, streamKey, consumerGroup, consumerName)
+	deadLetterQueue := consumer.NewDeadLetterQueue(redisClient, cfg.RedisDLQStreamKey, cfg.RedisStreamKey)
+	streamConsumer := consumer.NewStreamConsumer(redisClient, consumerConfig, deadLetterQueue)
 
 	// Initialize publisher
 	globalFeedChannel := cfg.RedisPubSubGlobalFeed
 	alertFeedChannel := cfg.RedisPubSubAlertFeed
-	feedPublisher := publisher.NewFeedPublisher(redisClient, globalFeedChannel, alertFeedChannel)
+	feedPublisher := publisher.NewFeedPublisher(redisClient, globalFeedChannel, alertFeedChannel, cfg.RedisPubSubUnknown)
+
+	// Initialize geofence incursion tracking
+	geofenceTracker := service.NewGeofenceTracker(
+		geofenceEventRepo,
+		feedPublisher,
+		time.Duration(cfg.GeofenceMaxDwell)*time.Second,
+	)
+	if err := geofenceTracker.Load(); err != nil {
+		logging.Fatal("Failed to load geofence incursions", zap.Error(err))
+	}
+
+	// Initialize anomaly incident tracking
+	anomalyEventService := service.NewAnomalyEventService(
+		anomalyEventRepo,
+		feedPublisher,
+		time.Duration(cfg.AnomalyEscalateAfter)*time.Second,
+	)
+	if err := anomalyEventService.Load(); err != nil {
+		logging.Fatal("Failed to load anomaly incidents", zap.Error(err))
+	}
+
+	// Initialize unknown aircraft handling
+	if cfg.AircraftAutoRegister && cfg.AircraftDefaultOwner == 0 {
+		logging.Fatal("Aircraft auto-registration requires a default owner")
+	}
+	unknownAircraftService := service.NewUnknownAircraftService(
+		unknownAircraftRepo,
+		aircraftService,
+		feedPublisher,
+		cfg.AircraftAutoRegister,
+		cfg.AircraftDefaultOwner,
+	)
+
+	// Initialize telemetry batcher
+	telemetryBatcher := service.NewTelemetryBatcher(
+		telemetryRepo,
+		streamConsumer,
+		cfg.TelemetryBatchSize,
+		time.Duration(cfg.TelemetryBatchLatency)*time.Millisecond,
+		cfg.WorkerLanes,
+	)
 
 	// Initialize worker service
 	workerService := service.NewWorkerService(
 		streamConsumer,
 		aircraftService,
+		unknownAircraftService,
 		anomalyService,
-		telemetryRepo,
+		geofenceTracker,
+		anomalyEventService,
+		telemetryBatcher,
 		feedPublisher,
+		quarantineRepo,
 	)
 
 	// Setup health check endpoint
 	http.HandleFunc("/health", HealthCheckHandler)
+	http.HandleFunc("/stats", StatsHandler(streamConsumer))
+	http.HandleFunc("/stats/aircraft-cache", AircraftCacheStatsHandler(aircraftService))
+
+	// Setup admin endpoints on their own listener, bound to an internal address and behind a token
+	if cfg.AdminEnabled {
+		if cfg.AdminAddr == "" || cfg.AdminToken == "" {
+			logging.Fatal("Admin endpoints require an admin address and token")
+		}
+
+		adminMux := http.NewServeMux()
+		adminMux.HandleFunc("/admin/dlq", AdminAuth(cfg.AdminToken, DeadLetterListHandler(deadLetterQueue)))
+		adminMux.HandleFunc("/admin/dlq/requeue", AdminAuth(cfg.AdminToken, DeadLetterRequeueHandler(deadLetterQueue)))
+		adminMux.HandleFunc("/admin/anomalies", AdminAuth(cfg.AdminToken, AnomalyEventListHandler(anomalyEventService)))
+		adminMux.HandleFunc("/admin/anomalies/ack", AdminAuth(cfg.AdminToken, AnomalyEventAcknowledgeHandler(anomalyEventService)))
+
+		go func() {
+			logging.Info("Admin server running", zap.String("addr", cfg.AdminAddr))
+			if err := http.ListenAndServe(cfg.AdminAddr, adminMux); err != nil {
+				logging.Fatal("Admin server error", zap.Error(err))
+			}
+		}()
+	}
 
 	// Start HTTP server in a goroutine
 	go func() {
@@ -159,6 +288,20 @@ func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintln(w, "OK")
 }
 
+// StatsHandler reports the stream consumer's lane and queue depth metrics
+func StatsHandler(streamConsumer consumer.StreamConsumer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, streamConsumer.Stats())
+	}
+}
+
+// AircraftCacheStatsHandler reports aircraft cache size and hit rate
+func AircraftCacheStatsHandler(aircraftService service.AircraftService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, aircraftService.CacheStats())
+	}
+}
+
 func getHostname() string {
 	hostname, err := os.Hostname()
 	if err != nil {
@@ -0,0 +1,168 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	redisv8 "github.com/go-redis/redis/v8"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/redis"
+)
+
+// DeadLetterQueue stores stream messages that could not be processed
+type DeadLetterQueue interface {
+	Send(ctx context.Context, message redisv8.XMessage, cause error, attempts int64, consumerName string) error
+	List(ctx context.Context, count int64) ([]*DeadLetterEntry, error)
+	Requeue(ctx context.Context, ids ...string) *RequeueResult
+}
+
+// RequeueResult reports which dead-letter entries were requeued and why the others were not
+type RequeueResult struct {
+	Requeued []string          `json:"requeued"`
+	Failed   map[string]string `json:"failed,omitempty"` // Dead-letter ID -> error
+}
+
+// DeadLetterEntry represents a single entry of the dead-letter stream
+type DeadLetterEntry struct {
+	ID         string                 `json:"id"`
+	OriginalID string                 `json:"original_id"`
+	Values     map[string]interface{} `json:"values"`
+	Error      string                 `json:"error"`
+	Attempts   int64                  `json:"attempts"`
+	Consumer   string                 `json:"consumer"`
+	FailedAt   time.Time              `json:"failed_at"`
+}
+
+type deadLetterQueue struct {
+	redisClient     redis.Client
+	dlqStreamKey    string
+	sourceStreamKey string
+}
+
+// NewDeadLetterQueue creates a new dead-letter queue backed by a Redis stream
+func NewDeadLetterQueue(redisClient redis.Client, dlqStreamKey, sourceStreamKey string) DeadLetterQueue {
+	return &deadLetterQueue{
+		redisClient:     redisClient,
+		dlqStreamKey:    dlqStreamKey,
+		sourceStreamKey: sourceStreamKey,
+	}
+}
+
+// Send writes the original message values along with failure metadata to the dead-letter stream
+func (q *deadLetterQueue) Send(ctx context.Context, message redisv8.XMessage, cause error, attempts int64, consumerName string) error {
+	values, err := json.Marshal(message.Values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead-letter values: %w", err)
+	}
+
+	errMsg := ""
+	if cause != nil {
+		errMsg = cause.Error()
+	}
+
+	data := map[string]interface{}{
+		"original_id": message.ID,
+		"values":      string(values),
+		"error":       errMsg,
+		"attempts":    attempts,
+		"consumer":    consumerName,
+		"failed_at":   time.Now(),
+	}
+
+	if err := q.redisClient.WriteToStream(ctx, q.dlqStreamKey, data); err != nil {
+		return fmt.Errorf("failed to write to dead-letter stream: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the oldest entries of the dead-letter stream
+func (q *deadLetterQueue) List(ctx context.Context, count int64) ([]*DeadLetterEntry, error) {
+	messages, err := q.redisClient.RangeStream(ctx, q.dlqStreamKey, "-", "+", count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead-letter stream: %w", err)
+	}
+
+	entries := make([]*DeadLetterEntry, 0, len(messages))
+	for _, message := range messages {
+		entries = append(entries, parseDeadLetter(message))
+	}
+
+	return entries, nil
+}
+
+// Requeue writes the original values of the given dead-letter entries back to the source stream
+// and removes them from the dead-letter stream. Each entry is moved in its own transaction, and a
+// failed entry doesn't stop the others.
+func (q *deadLetterQueue) Requeue(ctx context.Context, ids ...string) *RequeueResult {
+	result := &RequeueResult{Requeued: make([]string, 0, len(ids))}
+	for _, id := range ids {
+		if err := q.requeue(ctx, id); err != nil {
+			if result.Failed == nil {
+				result.Failed = make(map[string]string)
+			}
+			result.Failed[id] = err.Error()
+			continue
+		}
+		result.Requeued = append(result.Requeued, id)
+	}
+
+	return result
+}
+
+// requeue moves a single dead-letter entry back to the source stream
+func (q *deadLetterQueue) requeue(ctx context.Context, id string) error {
+	messages, err := q.redisClient.RangeStream(ctx, q.dlqStreamKey, id, id, 1)
+	if err != nil {
+		return fmt.Errorf("failed to read dead-letter entry: %w", err)
+	}
+	if len(messages) == 0 {
+		return fmt.Errorf("dead-letter entry not found")
+	}
+
+	entry := parseDeadLetter(messages[0])
+	if len(entry.Values) == 0 {
+		return fmt.Errorf("dead-letter entry has no values")
+	}
+
+	if err := q.redisClient.MoveToStream(ctx, q.dlqStreamKey, id, q.sourceStreamKey, entry.Values); err != nil {
+		return fmt.Errorf("failed to requeue dead-letter entry: %w", err)
+	}
+
+	return nil
+}
+
+// parseDeadLetter parses a dead-letter stream message into DeadLetterEntry
+func parseDeadLetter(message redisv8.XMessage) *DeadLetterEntry {
+	entry := &DeadLetterEntry{
+		ID: message.ID,
+	}
+
+	if originalID, ok := message.Values["original_id"].(string); ok {
+		entry.OriginalID = originalID
+	}
+
+	if values, ok := message.Values["values"].(string); ok {
+		_ = json.Unmarshal([]byte(values), &entry.Values)
+	}
+
+	if errMsg, ok := message.Values["error"].(string); ok {
+		entry.Error = errMsg
+	}
+
+	if attempts, ok := message.Values["attempts"].(string); ok {
+		entry.Attempts, _ = strconv.ParseInt(attempts, 10, 64)
+	}
+
+	if consumerName, ok := message.Values["consumer"].(string); ok {
+		entry.Consumer = consumerName
+	}
+
+	if failedAt, ok := message.Values["failed_at"].(string); ok {
+		entry.FailedAt, _ = time.Parse(time.RFC3339, failedAt)
+	}
+
+	return entry
+}
@@ -0,0 +1,208 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	redisv8 "github.com/go-redis/redis/v8"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/redis"
+)
+
+// memoryStreams is a redis.Client holding streams in memory. Values are stored as strings,
+// like Redis returns them; other methods are not implemented.
+type memoryStreams struct {
+	redis.Client
+	streams map[string][]redisv8.XMessage
+	nextID  int
+	moveErr map[string]error // Returned by MoveToStream per entry ID
+}
+
+func newMemoryStreams() *memoryStreams {
+	return &memoryStreams{streams: make(map[string][]redisv8.XMessage), moveErr: make(map[string]error)}
+}
+
+func (s *memoryStreams) WriteToStream(_ context.Context, streamKey string, data map[string]interface{}) error {
+	s.nextID++
+	values := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		if at, ok := value.(time.Time); ok {
+			value = at.Format(time.RFC3339)
+		}
+		values[key] = fmt.Sprint(value)
+	}
+	s.streams[streamKey] = append(s.streams[streamKey], redisv8.XMessage{ID: fmt.Sprintf("%d-0", s.nextID), Values: values})
+	return nil
+}
+
+func (s *memoryStreams) RangeStream(_ context.Context, streamKey, start, end string, count int64) ([]redisv8.XMessage, error) {
+	var messages []redisv8.XMessage
+	for _, message := range s.streams[streamKey] {
+		if (start == "-" || message.ID == start) && int64(len(messages)) < count {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
+
+// MoveToStream writes and deletes together, so a failed move leaves both streams unchanged
+func (s *memoryStreams) MoveToStream(ctx context.Context, fromStreamKey, id, toStreamKey string, data map[string]interface{}) error {
+	if err := s.moveErr[id]; err != nil {
+		return err
+	}
+	_ = s.WriteToStream(ctx, toStreamKey, data)
+	kept := s.streams[fromStreamKey][:0]
+	for _, message := range s.streams[fromStreamKey] {
+		if message.ID != id {
+			kept = append(kept, message)
+		}
+	}
+	s.streams[fromStreamKey] = kept
+	return nil
+}
+
+// streamIDs returns the IDs of a stream's entries
+func (s *memoryStreams) streamIDs(streamKey string) []string {
+	ids := make([]string, 0, len(s.streams[streamKey]))
+	for _, message := range s.streams[streamKey] {
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
+
+func TestDeadLetterQueueSendAndList(t *testing.T) {
+	streams := newMemoryStreams()
+	q := NewDeadLetterQueue(streams, "telemetry:dlq", "telemetry")
+
+	now := time.Now()
+	message := telemetryMessage("7-0", "plane", now, now)
+	if err := q.Send(context.Background(), message, errors.New("invalid payload"), 3, "worker-1"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	entries, err := q.List(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("List() returned %d entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.OriginalID != "7-0" || entry.Error != "invalid payload" || entry.Attempts != 3 || entry.Consumer != "worker-1" {
+		t.Errorf("entry = %+v, want original 7-0 failed with invalid payload after 3 attempts on worker-1", entry)
+	}
+	if entry.FailedAt.IsZero() {
+		t.Error("entry has no failure time")
+	}
+	if fmt.Sprint(entry.Values) != fmt.Sprint(message.Values) {
+		t.Errorf("entry values = %v, want the original %v", entry.Values, message.Values)
+	}
+}
+
+func TestDeadLetterQueueRequeue(t *testing.T) {
+	tests := []struct {
+		name         string
+		ids          []string
+		moveErr      map[string]error
+		wantRequeued []string
+		wantFailed   []string
+		wantLeft     []string // Dead-letter entries left afterwards
+	}{
+		{"all entries", []string{"1-0", "2-0"}, nil, []string{"1-0", "2-0"}, nil, []string{"3-0"}},
+		{"unknown entry", []string{"1-0", "9-0"}, nil, []string{"1-0"}, []string{"9-0"}, []string{"2-0", "3-0"}},
+		{"entry without values", []string{"3-0"}, nil, []string{}, []string{"3-0"}, []string{"1-0", "2-0", "3-0"}},
+		{
+			"failed move doesn't stop the others",
+			[]string{"1-0", "2-0"},
+			map[string]error{"1-0": errors.New("transaction aborted")},
+			[]string{"2-0"},
+			[]string{"1-0"},
+			[]string{"1-0", "3-0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streams := newMemoryStreams()
+			q := NewDeadLetterQueue(streams, "telemetry:dlq", "telemetry")
+			now := time.Now()
+			for _, id := range []string{"10-0", "11-0"} {
+				if err := q.Send(context.Background(), telemetryMessage(id, "plane", now, now), errors.New("failed"), 5, "test"); err != nil {
+					t.Fatalf("Send() error = %v", err)
+				}
+			}
+			// A dead-letter entry whose original values were lost
+			streams.streams["telemetry:dlq"] = append(streams.streams["telemetry:dlq"],
+				redisv8.XMessage{ID: "3-0", Values: map[string]interface{}{"original_id": "12-0"}})
+			for id, err := range tt.moveErr {
+				streams.moveErr[id] = err
+			}
+
+			result := q.Requeue(context.Background(), tt.ids...)
+
+			if fmt.Sprint(result.Requeued) != fmt.Sprint(tt.wantRequeued) {
+				t.Errorf("requeued = %v, want %v", result.Requeued, tt.wantRequeued)
+			}
+			failed := make([]string, 0, len(result.Failed))
+			for id := range result.Failed {
+				failed = append(failed, id)
+			}
+			sort.Strings(failed)
+			if fmt.Sprint(failed) != fmt.Sprint(tt.wantFailed) {
+				t.Errorf("failed = %v, want %v", result.Failed, tt.wantFailed)
+			}
+			if left := streams.streamIDs("telemetry:dlq"); fmt.Sprint(left) != fmt.Sprint(tt.wantLeft) {
+				t.Errorf("dead-letter entries left = %v, want %v", left, tt.wantLeft)
+			}
+
+			// Requeued entries carry the original message values, so they parse like new telemetry
+			if requeued := streams.streams["telemetry"]; len(requeued) != len(tt.wantRequeued) {
+				t.Errorf("source stream has %d entries, want %d", len(requeued), len(tt.wantRequeued))
+			} else {
+				for _, message := range requeued {
+					if message.Values["plane_id"] != "plane" || message.Values["data_json"] == "" {
+						t.Errorf("requeued values = %v, want the original message values", message.Values)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDispatchDeadLettersAfterMaxDeliveries(t *testing.T) {
+	tests := []struct {
+		name          string
+		deliveryCount int64
+		wantQueued    bool
+	}{
+		{"first delivery", 1, true},
+		{"last delivery", 3, true},
+		{"beyond max deliveries", 4, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, redisClient, deadLetterQueue := newTestConsumer(Config{MaxDeliveries: 3, WorkerLanes: 1, LaneBufferSize: 1})
+
+			now := time.Now()
+			c.dispatch(context.Background(), telemetryMessage("1-0", "plane", now, now), tt.deliveryCount)
+
+			if queued := len(c.lanes[0]) == 1; queued != tt.wantQueued {
+				t.Fatalf("queued = %v, want %v", queued, tt.wantQueued)
+			}
+			if tt.wantQueued {
+				if entry := <-c.lanes[0]; entry.DeliveryCount != tt.deliveryCount {
+					t.Errorf("delivery count = %d, want %d", entry.DeliveryCount, tt.deliveryCount)
+				}
+				return
+			}
+			if len(deadLetterQueue.sent) != 1 || len(redisClient.ackedIDs()) != 1 {
+				t.Errorf("dead-lettered %v and acked %v, want the entry dead-lettered and acked",
+					deadLetterQueue.sent, redisClient.ackedIDs())
+			}
+		})
+	}
+}
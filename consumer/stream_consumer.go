@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"sync"
 	"time"
 
@@ -15,78 +16,205 @@ import (
 	"go.uber.org/zap"
 )
 
+// ErrAckDeferred is returned by a handler that takes ownership of acknowledging an entry.
+// The handler must later report the outcome with StreamConsumer.Complete or CompleteBatch.
+var ErrAckDeferred = errors.New("stream entry acknowledgement deferred")
+
 // StreamConsumer handles reading from Redis Stream
 type StreamConsumer interface {
 	Consume(ctx context.Context, handler func(entry *StreamEntry) error) error
+	Complete(ctx context.Context, entry *StreamEntry, err error)
+	CompleteBatch(ctx context.Context, entries []*StreamEntry, errs []error)
+	Stats() Stats
 }
 
 // StreamEntry represents a single entry from Redis Stream
 type StreamEntry struct {
-	ID         string
-	PlaneID    string
-	Telemetry  *model.TelemetryDTO
-	ReceivedAt time.Time
+	ID            string
+	PlaneID       string
+	Telemetry     *model.TelemetryDTO
+	ReceivedAt    time.Time
+	DeliveryCount int64 // Number of times this entry has been delivered to a consumer
+
+	message redisv8.XMessage // Original stream message, kept for dead-lettering
 }
 
+// Config holds stream consumer configuration
+type Config struct {
+	StreamKey        string
+	GroupName        string
+	ConsumerName     string
+	ReclaimInterval  time.Duration // How often the pending entries list is scanned
+	ReclaimMinIdle   time.Duration // Minimum idle time before a pending entry is reclaimed
+	ReclaimBatchSize int64         // Maximum number of pending entries reclaimed per scan
+	MaxDeliveries    int64         // Deliveries after which a failing entry is dead-lettered
+	WorkerLanes      int           // Number of ordered lanes entries are sharded onto by plane ID
+	LaneBufferSize   int           // Entries a lane can queue before reading from the stream blocks
+	MaxFutureSkew    time.Duration // How far ahead of receive time a telemetry timestamp may be; <= 0 uses 5m
+	MaxPastAge       time.Duration // How far behind receive time a telemetry timestamp may be; <= 0 uses 24h
+}
+
+// Stats represents a snapshot of the consumer's processing state
+type Stats struct {
+	Lanes        int   `json:"lanes"`
+	QueueDepth   int   `json:"queue_depth"` // Entries waiting in all lanes
+	LaneDepths   []int `json:"lane_depths"` // Entries waiting per lane
+	Active       int64 `json:"active"`      // Entries currently being handled
+	InFlight     int   `json:"in_flight"`   // Entries queued, being handled or awaiting acknowledgement
+	Processed    int64 `json:"processed"`
+	Failed       int64 `json:"failed"`
+	DeadLettered int64 `json:"dead_lettered"`
+}
+
+const (
+	defaultReclaimInterval  = 30 * time.Second
+	defaultReclaimMinIdle   = 5 * time.Minute
+	defaultReclaimBatchSize = 100
+	defaultMaxDeliveries    = 5
+	defaultWorkerLanes      = 16
+	defaultLaneBufferSize   = 64
+	defaultMaxFutureSkew    = 5 * time.Minute
+	defaultMaxPastAge       = 24 * time.Hour
+
+	// Bounds of the delay between retries while reading from the stream keeps failing
+	readRetryMinBackoff = 100 * time.Millisecond
+	readRetryMaxBackoff = 10 * time.Second
+
+	// Assumed worst-case time to handle one entry queued on a lane. Entries stay pending while they wait,
+	// so ReclaimMinIdle is kept at twice a full lane's queue time to stop other consumers claiming them.
+	laneEntryBudget = time.Second
+)
+
 type streamConsumer struct {
-	redisClient      redis.Client
-	streamKey        string
-	groupName        string
-	consumerName     string
-	wg               sync.WaitGroup
-	mu               sync.Mutex
-	activeGoroutines int64 // Track active goroutines for monitoring
-}
-
-// NewStreamConsumer creates a new stream consumer
-func NewStreamConsumer(redisClient redis.Client, streamKey, groupName, consumerName string) StreamConsumer {
-	// workerCount parameter is kept for API compatibility but not used
-	// Each message is processed in its own goroutine
+	redisClient     redis.Client
+	config          Config
+	deadLetterQueue DeadLetterQueue
+	lanes           []chan *StreamEntry
+	wg              sync.WaitGroup
+	mu              sync.Mutex
+	active          int64               // Entries currently being handled
+	processed       int64               // Entries handled and acknowledged
+	failed          int64               // Entries whose handler returned an error
+	deadLettered    int64               // Entries moved to the dead-letter queue
+	inFlight        map[string]struct{} // IDs queued, being handled or awaiting acknowledgement
+	reclaimCursor   string              // Exclusive start of the next pending entries page; empty starts over
+}
+
+// NewStreamConsumer creates a new stream consumer.
+// Messages that fail parsing or exceed the maximum delivery count are moved to deadLetterQueue.
+func NewStreamConsumer(redisClient redis.Client, config Config, deadLetterQueue DeadLetterQueue) StreamConsumer {
+	if config.ReclaimInterval <= 0 {
+		config.ReclaimInterval = defaultReclaimInterval
+	}
+	if config.ReclaimMinIdle <= 0 {
+		config.ReclaimMinIdle = defaultReclaimMinIdle
+	}
+	if config.ReclaimBatchSize <= 0 {
+		config.ReclaimBatchSize = defaultReclaimBatchSize
+	}
+	if config.MaxDeliveries <= 0 {
+		config.MaxDeliveries = defaultMaxDeliveries
+	}
+	if config.WorkerLanes <= 0 {
+		config.WorkerLanes = defaultWorkerLanes
+	}
+	if config.LaneBufferSize <= 0 {
+		config.LaneBufferSize = defaultLaneBufferSize
+	}
+	if config.MaxFutureSkew <= 0 {
+		config.MaxFutureSkew = defaultMaxFutureSkew
+	}
+	if config.MaxPastAge <= 0 {
+		config.MaxPastAge = defaultMaxPastAge
+	}
+	minIdle := config.ReclaimInterval + 2*time.Duration(config.LaneBufferSize)*laneEntryBudget
+	if config.ReclaimMinIdle < minIdle {
+		logging.Warn("Reclaim min idle is below the worst-case lane queue time, raising it",
+			zap.Duration("reclaim_min_idle", config.ReclaimMinIdle),
+			zap.Duration("raised_to", minIdle),
+			zap.Int("lane_buffer_size", config.LaneBufferSize),
+		)
+		config.ReclaimMinIdle = minIdle
+	}
+
+	// Each lane processes its entries sequentially, so updates for the same plane stay ordered
+	lanes := make([]chan *StreamEntry, config.WorkerLanes)
+	for i := range lanes {
+		lanes[i] = make(chan *StreamEntry, config.LaneBufferSize)
+	}
+
 	return &streamConsumer{
-		redisClient:  redisClient,
-		streamKey:    streamKey,
-		groupName:    groupName,
-		consumerName: consumerName,
+		redisClient:     redisClient,
+		config:          config,
+		deadLetterQueue: deadLetterQueue,
+		lanes:           lanes,
+		inFlight:        make(map[string]struct{}),
 	}
 }
 
-// Consume reads from Redis Stream and processes each message in a separate goroutine
+// Consume reads from Redis Stream and processes messages on a fixed number of ordered lanes
 func (c *streamConsumer) Consume(ctx context.Context, handler func(entry *StreamEntry) error) error {
 	// Create consumer group if it doesn't exist
 	rdb := c.redisClient.GetRawClient()
-	_, err := rdb.XGroupCreateMkStream(ctx, c.streamKey, c.groupName, "0").Result()
+	_, err := rdb.XGroupCreateMkStream(ctx, c.config.StreamKey, c.config.GroupName, "0").Result()
 	if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
 		logging.Warn("Failed to create consumer group (may already exist)", zap.Error(err))
 	}
 
-	logging.Info("Stream consumer started (each message processed in separate goroutine)")
+	logging.Info("Stream consumer started",
+		zap.Int("worker_lanes", c.config.WorkerLanes),
+		zap.Int("lane_buffer_size", c.config.LaneBufferSize),
+		zap.Duration("reclaim_interval", c.config.ReclaimInterval),
+		zap.Duration("reclaim_min_idle", c.config.ReclaimMinIdle),
+	)
+
+	// Start lane workers
+	for _, lane := range c.lanes {
+		c.wg.Add(1)
+		go func(lane chan *StreamEntry) {
+			defer c.wg.Done()
+			c.runLane(ctx, lane, handler)
+		}(lane)
+	}
+
+	// Periodically reclaim entries left pending by failed handlers or dead consumers
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.reclaimLoop(ctx)
+	}()
 
-	// Main loop: Read from stream and process each message concurrently
+	// Main loop: Read from stream and hand messages to lanes.
+	// Dispatching blocks while the target lane is full, which throttles reading.
+	var readBackoff time.Duration
 	for {
 		select {
 		case <-ctx.Done():
-			// Wait for all active goroutines to complete
-			logging.Info("Waiting for active goroutines to complete", zap.Int64("active", c.getActiveGoroutines()))
+			// Wait for lanes to finish their current entries; queued entries stay pending and are reclaimed later
+			logging.Info("Waiting for active handlers to complete", zap.Int64("active", c.Stats().Active))
 			c.wg.Wait()
 			return ctx.Err()
 		default:
 			// Read from stream
-			streams, err := c.redisClient.ReadFromStream(ctx, c.streamKey, c.groupName, c.consumerName, 10)
+			streams, err := c.redisClient.ReadFromStream(ctx, c.config.StreamKey, c.config.GroupName, c.config.ConsumerName, 10)
 			if err != nil {
 				if err.Error() == "redis: nil" {
 					// No data available, continue
 					time.Sleep(100 * time.Millisecond)
 					continue
 				}
-				if !errors.Is(err, context.Canceled) {
-					logging.Error("Failed to read from stream", zap.Error(err))
+				if errors.Is(err, context.Canceled) {
 					continue
 				}
-				time.Sleep(1 * time.Second)
+
+				// Back off while Redis is unreachable instead of spinning on the error
+				readBackoff = min(max(readBackoff*2, readRetryMinBackoff), readRetryMaxBackoff)
+				logging.Error("Failed to read from stream", zap.Error(err), zap.Duration("retry_in", readBackoff))
+				sleepContext(ctx, readBackoff)
 				continue
 			}
+			readBackoff = 0
 
-			// Process each message in a separate goroutine
 			if len(streams) == 0 {
 				time.Sleep(100 * time.Millisecond)
 				continue
@@ -94,80 +222,328 @@ func (c *streamConsumer) Consume(ctx context.Context, handler func(entry *Stream
 
 			for _, stream := range streams {
 				for _, message := range stream.Messages {
-					entry, err := c.parseMessage(message)
-					if err != nil {
-						logging.Error("Failed to parse stream message",
-							zap.Error(err),
-							zap.String("id", message.ID),
-						)
-						// Acknowledge even if parsing failed to avoid reprocessing
-						if ackErr := c.redisClient.AcknowledgeStream(ctx, c.streamKey, c.groupName, message.ID); ackErr != nil {
-							logging.Error("Failed to acknowledge invalid message",
-								zap.Error(ackErr),
-								zap.String("id", message.ID),
-							)
-						}
-						continue
-					}
-
-					// Process each message in its own goroutine
-					c.wg.Add(1)
-					c.incrementActiveGoroutines()
-
-					go func(msg redisv8.XMessage, entry *StreamEntry) {
-						defer c.wg.Done()
-						defer c.decrementActiveGoroutines()
-
-						// Call handler
-						if err := handler(entry); err != nil {
-							logging.Error("Handler failed",
-								zap.Error(err),
-								zap.String("id", msg.ID),
-								zap.String("plane_id", entry.PlaneID),
-							)
-							// Don't acknowledge on handler error - message will be retried
-							return
-						}
-
-						// Acknowledge successful processing
-						if err := c.redisClient.AcknowledgeStream(ctx, c.streamKey, c.groupName, msg.ID); err != nil {
-							logging.Error("Failed to acknowledge message",
-								zap.Error(err),
-								zap.String("id", msg.ID),
-							)
-						}
-					}(message, entry)
+					c.dispatch(ctx, message, 1)
 				}
 			}
 		}
 	}
 }
 
-// getActiveGoroutines returns the current number of active goroutines
-func (c *streamConsumer) getActiveGoroutines() int64 {
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// dispatch parses a stream message and queues it on the lane owning its plane ID
+func (c *streamConsumer) dispatch(ctx context.Context, message redisv8.XMessage, deliveryCount int64) {
+	entry, err := c.parseMessage(message)
+	if err != nil {
+		logging.Error("Failed to parse stream message",
+			zap.Error(err),
+			zap.String("id", message.ID),
+		)
+		// Parse failures never succeed on retry, move them out of the stream right away
+		c.deadLetter(ctx, message, err, deliveryCount)
+		return
+	}
+
+	// Entry was redelivered too many times (e.g. consumers kept dying while handling it)
+	if deliveryCount > c.config.MaxDeliveries {
+		c.deadLetter(ctx, message, fmt.Errorf("max deliveries exceeded: %d", c.config.MaxDeliveries), deliveryCount)
+		return
+	}
+	entry.DeliveryCount = deliveryCount
+
+	c.markInFlight(message.ID)
+
+	select {
+	case c.lanes[c.laneFor(entry.PlaneID)] <- entry:
+	case <-ctx.Done():
+		// Not queued - the entry stays pending and is reclaimed later
+		c.clearInFlight(message.ID)
+	}
+}
+
+// laneFor returns the lane index for a plane ID
+func (c *streamConsumer) laneFor(planeID string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(planeID))
+	return int(h.Sum32() % uint32(len(c.lanes)))
+}
+
+// runLane handles the entries of a single lane one at a time
+func (c *streamConsumer) runLane(ctx context.Context, lane chan *StreamEntry, handler func(entry *StreamEntry) error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case entry := <-lane:
+			c.handle(ctx, entry, handler)
+		}
+	}
+}
+
+// handle calls the handler for a queued entry and completes it unless acknowledgement was deferred
+func (c *streamConsumer) handle(ctx context.Context, entry *StreamEntry, handler func(entry *StreamEntry) error) {
+	c.incrementActive()
+	err := handler(entry)
+	c.decrementActive()
+
+	if errors.Is(err, ErrAckDeferred) {
+		return
+	}
+
+	c.Complete(ctx, entry, err)
+}
+
+// Complete acknowledges a successfully handled entry, or leaves a failed one pending for retry
+// and dead-letters it once it has used up its deliveries
+func (c *streamConsumer) Complete(ctx context.Context, entry *StreamEntry, err error) {
+	c.CompleteBatch(ctx, []*StreamEntry{entry}, []error{err})
+}
+
+// CompleteBatch completes entries with the matching errors like Complete, acknowledging all
+// successfully handled entries with a single XACK
+func (c *streamConsumer) CompleteBatch(ctx context.Context, entries []*StreamEntry, errs []error) {
+	ids := make([]string, 0, len(entries))
+	for i, entry := range entries {
+		if err := errs[i]; err != nil {
+			c.incrementFailed()
+			logging.Error("Handler failed",
+				zap.Error(err),
+				zap.String("id", entry.ID),
+				zap.String("plane_id", entry.PlaneID),
+				zap.Int64("delivery_count", entry.DeliveryCount),
+			)
+			if entry.DeliveryCount >= c.config.MaxDeliveries {
+				c.deadLetter(ctx, entry.message, err, entry.DeliveryCount)
+			}
+			// Don't acknowledge on handler error - message will be reclaimed and retried
+			c.clearInFlight(entry.ID)
+			continue
+		}
+		ids = append(ids, entry.ID)
+	}
+	defer func() {
+		for _, id := range ids {
+			c.clearInFlight(id)
+		}
+	}()
+
+	// Acknowledge successful processing
+	if err := c.redisClient.AcknowledgeStream(ctx, c.config.StreamKey, c.config.GroupName, ids...); err != nil {
+		logging.Error("Failed to acknowledge messages",
+			zap.Error(err),
+			zap.Strings("ids", ids),
+		)
+		return
+	}
+	c.addProcessed(int64(len(ids)))
+}
+
+// deadLetter moves a message to the dead-letter queue and acknowledges it.
+// The message stays pending if it could not be written, so it is retried on the next reclaim.
+func (c *streamConsumer) deadLetter(ctx context.Context, message redisv8.XMessage, cause error, attempts int64) {
+	if c.deadLetterQueue != nil {
+		if err := c.deadLetterQueue.Send(ctx, message, cause, attempts, c.config.ConsumerName); err != nil {
+			logging.Error("Failed to dead-letter message",
+				zap.Error(err),
+				zap.String("id", message.ID),
+			)
+			return
+		}
+
+		logging.Warn("Message moved to dead-letter queue",
+			zap.String("id", message.ID),
+			zap.Int64("attempts", attempts),
+			zap.NamedError("cause", cause),
+		)
+		c.incrementDeadLettered()
+	}
+
+	if err := c.redisClient.AcknowledgeStream(ctx, c.config.StreamKey, c.config.GroupName, message.ID); err != nil {
+		logging.Error("Failed to acknowledge dead-lettered message",
+			zap.Error(err),
+			zap.String("id", message.ID),
+		)
+	}
+}
+
+// reclaimLoop periodically redelivers entries that have been pending for too long
+func (c *streamConsumer) reclaimLoop(ctx context.Context) {
+	ticker := time.NewTicker(c.config.ReclaimInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.reclaimPending(ctx)
+		}
+	}
+}
+
+// reclaimPending claims idle pending entries from any consumer in the group
+// (including consumers of pods that no longer exist) and redelivers them to the handler.
+// Each call scans the next page of the pending entries list, so entries this consumer is still
+// working on can't keep the same page filled and starve the ones after them.
+func (c *streamConsumer) reclaimPending(ctx context.Context) {
+	start := c.reclaimCursor
+	if start == "" {
+		start = "-"
+	}
+
+	pending, err := c.redisClient.PendingFromStream(ctx, c.config.StreamKey, c.config.GroupName, start, c.config.ReclaimMinIdle, c.config.ReclaimBatchSize)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			logging.Error("Failed to list pending stream entries", zap.Error(err))
+		}
+		return
+	}
+
+	// Continue after the last entry next time, or start over once the end of the list is reached
+	c.reclaimCursor = ""
+	if int64(len(pending)) == c.config.ReclaimBatchSize {
+		c.reclaimCursor = "(" + pending[len(pending)-1].ID
+	}
+
+	deliveryCounts := make(map[string]int64, len(pending))
+	ids := make([]string, 0, len(pending))
+	for _, p := range pending {
+		// Skip entries this consumer has queued or is still working on
+		if c.isInFlight(p.ID) {
+			continue
+		}
+		// XCLAIM increments the delivery counter of each claimed entry
+		deliveryCounts[p.ID] = p.RetryCount + 1
+		ids = append(ids, p.ID)
+	}
+
+	if len(ids) == 0 {
+		return
+	}
+
+	messages, err := c.redisClient.ClaimFromStream(ctx, c.config.StreamKey, c.config.GroupName, c.config.ConsumerName, c.config.ReclaimMinIdle, ids...)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			logging.Error("Failed to claim pending stream entries", zap.Error(err))
+		}
+		return
+	}
+
+	logging.Info("Reclaimed pending stream entries",
+		zap.Int("pending", len(ids)),
+		zap.Int("claimed", len(messages)),
+	)
+
+	for _, message := range messages {
+		// Entry was trimmed from the stream while pending, nothing left to process
+		if message.Values == nil {
+			if err := c.redisClient.AcknowledgeStream(ctx, c.config.StreamKey, c.config.GroupName, message.ID); err != nil {
+				logging.Error("Failed to acknowledge trimmed message",
+					zap.Error(err),
+					zap.String("id", message.ID),
+				)
+			}
+			continue
+		}
+
+		c.dispatch(ctx, message, deliveryCounts[message.ID])
+	}
+}
+
+// markInFlight records that an entry is being handled by this consumer
+func (c *streamConsumer) markInFlight(id string) {
+	c.mu.Lock()
+	c.inFlight[id] = struct{}{}
+	c.mu.Unlock()
+}
+
+// clearInFlight removes an entry from the in-flight set
+func (c *streamConsumer) clearInFlight(id string) {
+	c.mu.Lock()
+	delete(c.inFlight, id)
+	c.mu.Unlock()
+}
+
+// isInFlight reports whether an entry is currently being handled by this consumer
+func (c *streamConsumer) isInFlight(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.inFlight[id]
+	return ok
+}
+
+// Stats returns a snapshot of the consumer's processing state
+func (c *streamConsumer) Stats() Stats {
+	laneDepths := make([]int, len(c.lanes))
+	queueDepth := 0
+	for i, lane := range c.lanes {
+		laneDepths[i] = len(lane)
+		queueDepth += laneDepths[i]
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	return c.activeGoroutines
+	return Stats{
+		Lanes:        len(c.lanes),
+		QueueDepth:   queueDepth,
+		LaneDepths:   laneDepths,
+		Active:       c.active,
+		InFlight:     len(c.inFlight),
+		Processed:    c.processed,
+		Failed:       c.failed,
+		DeadLettered: c.deadLettered,
+	}
+}
+
+// incrementActive increments the active handler counter
+func (c *streamConsumer) incrementActive() {
+	c.mu.Lock()
+	c.active++
+	c.mu.Unlock()
+}
+
+// decrementActive decrements the active handler counter
+func (c *streamConsumer) decrementActive() {
+	c.mu.Lock()
+	c.active--
+	c.mu.Unlock()
+}
+
+// addProcessed adds to the processed entry counter
+func (c *streamConsumer) addProcessed(n int64) {
+	c.mu.Lock()
+	c.processed += n
+	c.mu.Unlock()
 }
 
-// incrementActiveGoroutines increments the active goroutine counter
-func (c *streamConsumer) incrementActiveGoroutines() {
+// incrementFailed increments the failed entry counter
+func (c *streamConsumer) incrementFailed() {
 	c.mu.Lock()
-	c.activeGoroutines++
+	c.failed++
 	c.mu.Unlock()
 }
 
-// decrementActiveGoroutines decrements the active goroutine counter
-func (c *streamConsumer) decrementActiveGoroutines() {
+// incrementDeadLettered increments the dead-lettered entry counter
+func (c *streamConsumer) incrementDeadLettered() {
 	c.mu.Lock()
-	c.activeGoroutines--
+	c.deadLettered++
 	c.mu.Unlock()
 }
 
 // parseMessage parses a Redis stream message into StreamEntry
 func (c *streamConsumer) parseMessage(message redisv8.XMessage) (*StreamEntry, error) {
 	entry := &StreamEntry{
-		ID: message.ID,
+		ID:      message.ID,
+		message: message,
 	}
 
 	// Extract plane_id
@@ -201,5 +577,10 @@ func (c *streamConsumer) parseMessage(message redisv8.XMessage) (*StreamEntry, e
 		entry.ReceivedAt = time.Now()
 	}
 
+	// Resolve the event time, falling back to the receive time when the timestamp is missing
+	if err := entry.Telemetry.ResolveTimestamp(entry.ReceivedAt, c.config.MaxFutureSkew, c.config.MaxPastAge); err != nil {
+		return nil, err
+	}
+
 	return entry, nil
 }
@@ -0,0 +1,473 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	redisv8 "github.com/go-redis/redis/v8"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/logging"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/redis"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestMain sets up the logger once for every consumer test
+func TestMain(m *testing.M) {
+	logging.CreateLogger(zapcore.ErrorLevel)
+	os.Exit(m.Run())
+}
+
+// ackRecorder is a redis.Client that records acknowledged IDs; other methods are not implemented
+type ackRecorder struct {
+	redis.Client
+	mu    sync.Mutex
+	acked []string
+}
+
+func (r *ackRecorder) AcknowledgeStream(_ context.Context, _, _ string, ids ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.acked = append(r.acked, ids...)
+	return nil
+}
+
+func (r *ackRecorder) ackedIDs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.acked...)
+}
+
+// memoryDeadLetterQueue is a DeadLetterQueue that records sent message IDs
+type memoryDeadLetterQueue struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (q *memoryDeadLetterQueue) Send(_ context.Context, message redisv8.XMessage, _ error, _ int64, _ string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.sent = append(q.sent, message.ID)
+	return nil
+}
+
+func (q *memoryDeadLetterQueue) List(context.Context, int64) ([]*DeadLetterEntry, error) {
+	return nil, nil
+}
+
+func (q *memoryDeadLetterQueue) Requeue(context.Context, ...string) *RequeueResult {
+	return &RequeueResult{}
+}
+
+func newTestConsumer(config Config) (*streamConsumer, *ackRecorder, *memoryDeadLetterQueue) {
+	redisClient := &ackRecorder{}
+	deadLetterQueue := &memoryDeadLetterQueue{}
+	config.StreamKey = "telemetry"
+	config.GroupName = "workers"
+	config.ConsumerName = "test"
+	return NewStreamConsumer(redisClient, config, deadLetterQueue).(*streamConsumer), redisClient, deadLetterQueue
+}
+
+// telemetryMessage builds a stream message for a plane; a zero timestamp is left out of the payload
+func telemetryMessage(id, planeID string, timestamp, receivedAt time.Time) redisv8.XMessage {
+	data := map[string]interface{}{"planeId": planeID, "lat": 41.0, "lon": 29.0}
+	if !timestamp.IsZero() {
+		data["timestamp"] = timestamp.Format(time.RFC3339Nano)
+	}
+	dataJSON, _ := json.Marshal(data)
+
+	return redisv8.XMessage{
+		ID: id,
+		Values: map[string]interface{}{
+			"plane_id":    planeID,
+			"data_json":   string(dataJSON),
+			"received_at": receivedAt.Format(time.RFC3339),
+		},
+	}
+}
+
+func TestLanesKeepPerPlaneOrder(t *testing.T) {
+	const planes, perPlane = 6, 25
+
+	c, redisClient, _ := newTestConsumer(Config{WorkerLanes: 3, LaneBufferSize: 4})
+
+	var mu sync.Mutex
+	seen := make(map[string][]int)
+	handler := func(entry *StreamEntry) error {
+		var seq int
+		_, _ = fmt.Sscanf(entry.ID, "%d-", &seq)
+		// Uneven handling times would reorder entries if a plane's entries ran concurrently
+		time.Sleep(time.Duration(seq%3) * 100 * time.Microsecond)
+
+		mu.Lock()
+		seen[entry.PlaneID] = append(seen[entry.PlaneID], seq)
+		mu.Unlock()
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, lane := range c.lanes {
+		go c.runLane(ctx, lane, handler)
+	}
+
+	now := time.Now()
+	for seq := 0; seq < planes*perPlane; seq++ {
+		planeID := fmt.Sprintf("plane-%d", seq%planes)
+		c.dispatch(ctx, telemetryMessage(fmt.Sprintf("%d-0", seq), planeID, now, now), 1)
+	}
+
+	deadline := time.After(5 * time.Second)
+	for len(redisClient.ackedIDs()) < planes*perPlane {
+		select {
+		case <-deadline:
+			t.Fatalf("acked %d of %d entries", len(redisClient.ackedIDs()), planes*perPlane)
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for planeID, seqs := range seen {
+		if len(seqs) != perPlane {
+			t.Errorf("%s handled %d entries, want %d", planeID, len(seqs), perPlane)
+		}
+		for i := 1; i < len(seqs); i++ {
+			if seqs[i] < seqs[i-1] {
+				t.Errorf("%s handled entry %d after %d", planeID, seqs[i], seqs[i-1])
+			}
+		}
+	}
+	if stats := c.Stats(); stats.InFlight != 0 || stats.Processed != planes*perPlane {
+		t.Errorf("Stats() = %+v, want nothing in flight and %d processed", stats, planes*perPlane)
+	}
+}
+
+func TestDispatchBlocksWhileLaneIsFull(t *testing.T) {
+	c, _, _ := newTestConsumer(Config{WorkerLanes: 1, LaneBufferSize: 2})
+
+	ctx := context.Background()
+	now := time.Now()
+	c.dispatch(ctx, telemetryMessage("1-0", "plane", now, now), 1)
+	c.dispatch(ctx, telemetryMessage("2-0", "plane", now, now), 1)
+
+	dispatched := make(chan struct{})
+	go func() {
+		c.dispatch(ctx, telemetryMessage("3-0", "plane", now, now), 1)
+		close(dispatched)
+	}()
+
+	select {
+	case <-dispatched:
+		t.Fatal("dispatch returned while the lane was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if stats := c.Stats(); stats.QueueDepth != 2 || stats.InFlight != 3 {
+		t.Errorf("Stats() = %+v, want 2 queued and 3 in flight", stats)
+	}
+
+	// Handling one entry frees a slot for the blocked dispatch
+	if entry := <-c.lanes[0]; entry.ID != "1-0" {
+		t.Errorf("first queued entry = %s, want 1-0", entry.ID)
+	}
+	select {
+	case <-dispatched:
+	case <-time.After(time.Second):
+		t.Fatal("dispatch still blocked after the lane drained")
+	}
+}
+
+func TestDispatchGivesUpWhenCancelled(t *testing.T) {
+	c, _, _ := newTestConsumer(Config{WorkerLanes: 1, LaneBufferSize: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	now := time.Now()
+	c.dispatch(ctx, telemetryMessage("1-0", "plane", now, now), 1)
+
+	dispatched := make(chan struct{})
+	go func() {
+		c.dispatch(ctx, telemetryMessage("2-0", "plane", now, now), 1)
+		close(dispatched)
+	}()
+
+	cancel()
+	select {
+	case <-dispatched:
+	case <-time.After(time.Second):
+		t.Fatal("dispatch still blocked after cancellation")
+	}
+
+	// The entry that wasn't queued stays pending for reclaim, so it must not count as in flight
+	if stats := c.Stats(); stats.InFlight != 1 {
+		t.Errorf("in flight = %d, want 1", stats.InFlight)
+	}
+}
+
+func TestDispatchRejectsTimestampsOutsideSkewWindow(t *testing.T) {
+	receivedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		timestamp    time.Time
+		wantQueued   bool
+		wantResolved time.Time
+	}{
+		{"within window", receivedAt.Add(-time.Minute), true, receivedAt.Add(-time.Minute)},
+		{"missing timestamp uses receive time", time.Time{}, true, receivedAt},
+		{"at future skew limit", receivedAt.Add(5 * time.Minute), true, receivedAt.Add(5 * time.Minute)},
+		{"beyond future skew", receivedAt.Add(5*time.Minute + time.Second), false, time.Time{}},
+		{"at past age limit", receivedAt.Add(-time.Hour), true, receivedAt.Add(-time.Hour)},
+		{"beyond past age", receivedAt.Add(-time.Hour - time.Second), false, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, redisClient, deadLetterQueue := newTestConsumer(Config{
+				WorkerLanes:    1,
+				LaneBufferSize: 1,
+				MaxFutureSkew:  5 * time.Minute,
+				MaxPastAge:     time.Hour,
+			})
+
+			c.dispatch(context.Background(), telemetryMessage("1-0", "plane", tt.timestamp, receivedAt), 1)
+
+			if !tt.wantQueued {
+				if len(c.lanes[0]) != 0 {
+					t.Fatal("entry was queued, want it rejected")
+				}
+				if len(deadLetterQueue.sent) != 1 || len(redisClient.ackedIDs()) != 1 {
+					t.Errorf("dead-lettered %v and acked %v, want the entry dead-lettered and acked",
+						deadLetterQueue.sent, redisClient.ackedIDs())
+				}
+				return
+			}
+
+			if len(c.lanes[0]) != 1 {
+				t.Fatalf("entry was not queued (dead-lettered %v)", deadLetterQueue.sent)
+			}
+			entry := <-c.lanes[0]
+			if got := entry.Telemetry.Timestamp.Time; !got.Equal(tt.wantResolved) {
+				t.Errorf("timestamp = %s, want %s", got, tt.wantResolved)
+			}
+		})
+	}
+}
+
+// Unset timestamp limits fall back to the defaults rather than disabling the checks
+func TestDispatchUsesDefaultSkewWindow(t *testing.T) {
+	receivedAt := time.Now()
+	tests := []struct {
+		name       string
+		timestamp  time.Time
+		wantQueued bool
+	}{
+		{"within default window", receivedAt.Add(-23 * time.Hour), true},
+		{"beyond default future skew", receivedAt.Add(5*time.Minute + time.Second), false},
+		{"beyond default past age", receivedAt.Add(-24*time.Hour - time.Second), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, _ := newTestConsumer(Config{WorkerLanes: 1, LaneBufferSize: 1, MaxPastAge: -time.Hour})
+
+			c.dispatch(context.Background(), telemetryMessage("1-0", "plane", tt.timestamp, receivedAt), 1)
+
+			if queued := len(c.lanes[0]) == 1; queued != tt.wantQueued {
+				t.Errorf("queued = %v, want %v", queued, tt.wantQueued)
+			}
+		})
+	}
+}
+
+// pendingStream is a redis.Client holding a consumer group's pending entries list, sorted by ID.
+// Claimed entries are returned as telemetry messages, or without values if they were trimmed.
+type pendingStream struct {
+	ackRecorder
+	pending []redisv8.XPendingExt
+	trimmed map[string]bool
+	starts  []string // start of every PendingFromStream call
+	claimed []string
+}
+
+func (s *pendingStream) PendingFromStream(_ context.Context, _, _, start string, _ time.Duration, count int64) ([]redisv8.XPendingExt, error) {
+	s.starts = append(s.starts, start)
+	from := 0
+	if start != "-" {
+		exclusive := strings.HasPrefix(start, "(")
+		id := strings.TrimPrefix(start, "(")
+		for from < len(s.pending) && s.pending[from].ID != id {
+			from++
+		}
+		if exclusive {
+			from++
+		}
+	}
+	from = min(from, len(s.pending))
+	return s.pending[from:min(from+int(count), len(s.pending))], nil
+}
+
+func (s *pendingStream) ClaimFromStream(_ context.Context, _, _, _ string, _ time.Duration, ids ...string) ([]redisv8.XMessage, error) {
+	s.claimed = append(s.claimed, ids...)
+	now := time.Now()
+	messages := make([]redisv8.XMessage, len(ids))
+	for i, id := range ids {
+		messages[i] = telemetryMessage(id, "plane", now, now)
+		if s.trimmed[id] {
+			messages[i].Values = nil
+		}
+	}
+	return messages, nil
+}
+
+// pendingEntries returns pending entries "1-0" to "n-0" delivered retryCount times
+func pendingEntries(n int, retryCount int64) []redisv8.XPendingExt {
+	pending := make([]redisv8.XPendingExt, n)
+	for i := range pending {
+		pending[i] = redisv8.XPendingExt{ID: fmt.Sprintf("%d-0", i+1), RetryCount: retryCount}
+	}
+	return pending
+}
+
+// queuedEntries drains a lane and returns the IDs and delivery counts of its entries
+func queuedEntries(lane chan *StreamEntry) map[string]int64 {
+	queued := make(map[string]int64)
+	for len(lane) > 0 {
+		entry := <-lane
+		queued[entry.ID] = entry.DeliveryCount
+	}
+	return queued
+}
+
+func TestReclaimPendingPagesThroughPendingEntries(t *testing.T) {
+	stream := &pendingStream{pending: pendingEntries(5, 1)}
+	c := NewStreamConsumer(stream, Config{
+		StreamKey: "telemetry", GroupName: "workers", ConsumerName: "test",
+		ReclaimBatchSize: 2, WorkerLanes: 1, LaneBufferSize: 10,
+	}, &memoryDeadLetterQueue{}).(*streamConsumer)
+
+	for i := 0; i < 4; i++ {
+		c.reclaimPending(context.Background())
+	}
+
+	// A full page continues after its last entry; a short one starts over from the beginning
+	wantStarts := []string{"-", "(2-0", "(4-0", "-"}
+	if fmt.Sprint(stream.starts) != fmt.Sprint(wantStarts) {
+		t.Errorf("pending list starts = %v, want %v", stream.starts, wantStarts)
+	}
+	// The second pass finds every entry already in flight, so nothing is claimed twice
+	wantClaimed := []string{"1-0", "2-0", "3-0", "4-0", "5-0"}
+	if fmt.Sprint(stream.claimed) != fmt.Sprint(wantClaimed) {
+		t.Errorf("claimed = %v, want %v", stream.claimed, wantClaimed)
+	}
+	if queued := queuedEntries(c.lanes[0]); len(queued) != 5 {
+		t.Errorf("queued %v, want 5 entries", queued)
+	}
+}
+
+func TestReclaimPendingClaimsEntries(t *testing.T) {
+	tests := []struct {
+		name             string
+		retryCount       int64
+		inFlight         []string
+		trimmed          []string
+		wantClaimed      []string
+		wantQueued       map[string]int64 // ID -> delivery count
+		wantAcked        []string
+		wantDeadLettered []string
+	}{
+		{
+			name:        "claimed entries count one more delivery",
+			retryCount:  2,
+			wantClaimed: []string{"1-0", "2-0", "3-0"},
+			wantQueued:  map[string]int64{"1-0": 3, "2-0": 3, "3-0": 3},
+		},
+		{
+			name:        "in-flight entries are skipped",
+			retryCount:  1,
+			inFlight:    []string{"2-0"},
+			wantClaimed: []string{"1-0", "3-0"},
+			wantQueued:  map[string]int64{"1-0": 2, "3-0": 2},
+		},
+		{
+			name:        "trimmed entries are acknowledged",
+			retryCount:  1,
+			trimmed:     []string{"2-0"},
+			wantClaimed: []string{"1-0", "2-0", "3-0"},
+			wantQueued:  map[string]int64{"1-0": 2, "3-0": 2},
+			wantAcked:   []string{"2-0"},
+		},
+		{
+			name:             "entries beyond max deliveries are dead-lettered",
+			retryCount:       3,
+			wantClaimed:      []string{"1-0", "2-0", "3-0"},
+			wantQueued:       map[string]int64{},
+			wantAcked:        []string{"1-0", "2-0", "3-0"},
+			wantDeadLettered: []string{"1-0", "2-0", "3-0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &pendingStream{pending: pendingEntries(3, tt.retryCount), trimmed: make(map[string]bool)}
+			for _, id := range tt.trimmed {
+				stream.trimmed[id] = true
+			}
+			deadLetterQueue := &memoryDeadLetterQueue{}
+			c := NewStreamConsumer(stream, Config{
+				StreamKey: "telemetry", GroupName: "workers", ConsumerName: "test",
+				MaxDeliveries: 3, WorkerLanes: 1, LaneBufferSize: 10,
+			}, deadLetterQueue).(*streamConsumer)
+			for _, id := range tt.inFlight {
+				c.markInFlight(id)
+			}
+
+			c.reclaimPending(context.Background())
+
+			if fmt.Sprint(stream.claimed) != fmt.Sprint(tt.wantClaimed) {
+				t.Errorf("claimed = %v, want %v", stream.claimed, tt.wantClaimed)
+			}
+			if queued := queuedEntries(c.lanes[0]); fmt.Sprint(queued) != fmt.Sprint(tt.wantQueued) {
+				t.Errorf("queued = %v, want %v", queued, tt.wantQueued)
+			}
+			if acked := stream.ackedIDs(); fmt.Sprint(acked) != fmt.Sprint(tt.wantAcked) {
+				t.Errorf("acked = %v, want %v", acked, tt.wantAcked)
+			}
+			if fmt.Sprint(deadLetterQueue.sent) != fmt.Sprint(tt.wantDeadLettered) {
+				t.Errorf("dead-lettered = %v, want %v", deadLetterQueue.sent, tt.wantDeadLettered)
+			}
+		})
+	}
+}
+
+func TestCompleteBatchDeadLettersAtMaxDeliveries(t *testing.T) {
+	c, redisClient, deadLetterQueue := newTestConsumer(Config{MaxDeliveries: 3, WorkerLanes: 1, LaneBufferSize: 10})
+
+	ctx := context.Background()
+	now := time.Now()
+	for i, deliveryCount := range []int64{1, 2, 3} {
+		c.dispatch(ctx, telemetryMessage(fmt.Sprintf("%d-0", i+1), "plane", now, now), deliveryCount)
+	}
+	c.dispatch(ctx, telemetryMessage("4-0", "plane", now, now), 3)
+
+	entries := make([]*StreamEntry, 0, 4)
+	for len(c.lanes[0]) > 0 {
+		entries = append(entries, <-c.lanes[0])
+	}
+	failed := errors.New("database unavailable")
+	c.CompleteBatch(ctx, entries, []error{failed, failed, failed, nil})
+
+	// Failed entries stay pending for retry until their last delivery fails
+	if fmt.Sprint(deadLetterQueue.sent) != "[3-0]" {
+		t.Errorf("dead-lettered = %v, want [3-0]", deadLetterQueue.sent)
+	}
+	if acked := redisClient.ackedIDs(); fmt.Sprint(acked) != "[3-0 4-0]" {
+		t.Errorf("acked = %v, want [3-0 4-0]", acked)
+	}
+	if stats := c.Stats(); stats.InFlight != 0 || stats.Failed != 3 || stats.DeadLettered != 1 || stats.Processed != 1 {
+		t.Errorf("Stats() = %+v, want nothing in flight, 3 failed, 1 dead-lettered and 1 processed", stats)
+	}
+}
@@ -1,21 +1,50 @@
 package model
 
 import (
+	"fmt"
+
 	"gorm.io/gorm"
 )
 
+// AircraftStatus represents the lifecycle status of an aircraft
+type AircraftStatus string
+
+const (
+	AircraftStatusActive      AircraftStatus = "active"      // Normal processing
+	AircraftStatusMaintenance AircraftStatus = "maintenance" // Telemetry stored, alerts suppressed
+	AircraftStatusGrounded    AircraftStatus = "grounded"    // Movement raises an unauthorized_movement anomaly
+	AircraftStatusRetired     AircraftStatus = "retired"     // Telemetry quarantined
+)
+
 // Aircraft represents an aircraft in the system
 type Aircraft struct {
 	gorm.Model
-	MACAddress       string `gorm:"uniqueIndex;not null" json:"mac_address"`
-	Name             string `gorm:"not null" json:"name"`
-	CurrentAirportID *uint  `json:"current_airport_id,omitempty"`
-	AssignedPilotID  *uint  `json:"assigned_pilot_id,omitempty"`
-	OwnerID          uint   `gorm:"not null" json:"owner_id"`
-	Status           string `gorm:"default:active" json:"status"`
+	MACAddress       string         `gorm:"uniqueIndex;not null" json:"mac_address"`
+	Name             string         `gorm:"not null" json:"name"`
+	CurrentAirportID *uint          `json:"current_airport_id,omitempty"`
+	AssignedPilotID  *uint          `json:"assigned_pilot_id,omitempty"`
+	OwnerID          uint           `gorm:"not null" json:"owner_id"`
+	Status           AircraftStatus `gorm:"default:active" json:"status"`
 }
 
 // TableName specifies the table name for Aircraft
 func (Aircraft) TableName() string {
 	return "aircraft"
 }
+
+// IsValid reports whether the status is a known lifecycle status
+func (s AircraftStatus) IsValid() bool {
+	switch s {
+	case AircraftStatusActive, AircraftStatusMaintenance, AircraftStatusGrounded, AircraftStatusRetired:
+		return true
+	}
+	return false
+}
+
+// BeforeSave rejects unknown statuses; an empty status is stored as the column default (active)
+func (a *Aircraft) BeforeSave(tx *gorm.DB) error {
+	if a.Status != "" && !a.Status.IsValid() {
+		return fmt.Errorf("invalid aircraft status: %q", a.Status)
+	}
+	return nil
+}
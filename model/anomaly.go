@@ -7,11 +7,32 @@ const (
 	AnomalyTypeThreshold AnomalyType = "threshold"
 	AnomalyTypeGeofence  AnomalyType = "geofence"
 	AnomalyTypeBoth      AnomalyType = "both"
+
+	AnomalyTypeApproachingGeofence  AnomalyType = "approaching_geofence"
+	AnomalyTypeUnauthorizedMovement AnomalyType = "unauthorized_movement"
 )
 
 // Anomaly represents detected anomaly information
 type Anomaly struct {
 	HasAnomaly  bool        `json:"has_anomaly"`
 	AnomalyType AnomalyType `json:"anomaly_type,omitempty"`
+	Severity    Severity    `json:"severity,omitempty"` // Most serious violation
 	Details     string      `json:"details,omitempty"`
+	Violations  []Violation `json:"violations,omitempty"` // Ordered by severity, then kind, then metric or geofence
+
+	// Geofences the projected track enters within the look-ahead, ordered by time to entry
+	Approaches []GeofenceApproach `json:"approaches,omitempty"`
+
+	// Geofences the position is inside, used to track incursions
+	Geofences []*Geofence `json:"-"`
+}
+
+// GeofenceApproach represents a predicted entry of an aircraft into a geofence
+type GeofenceApproach struct {
+	GeofenceID         uint    `json:"geofence_id"`
+	GeofenceName       string  `json:"geofence_name"`
+	TimeToEntrySeconds float64 `json:"time_to_entry_seconds"`
+	EntryLatitude      float64 `json:"entry_latitude"`
+	EntryLongitude     float64 `json:"entry_longitude"`
+	EntryAltitude      float64 `json:"entry_altitude"`
 }
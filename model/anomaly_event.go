@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+)
+
+// AnomalyEventStatus represents the lifecycle state of an anomaly incident
+type AnomalyEventStatus string
+
+const (
+	AnomalyEventOpen         AnomalyEventStatus = "open"
+	AnomalyEventAcknowledged AnomalyEventStatus = "acknowledged"
+	AnomalyEventResolved     AnomalyEventStatus = "resolved"
+)
+
+// AnomalyEvent represents an anomaly incident of one kind for an aircraft, from the first anomalous
+// sample until telemetry returns to normal
+type AnomalyEvent struct {
+	ID             uint               `gorm:"primaryKey" json:"id"`
+	AircraftID     uint               `gorm:"index;not null" json:"aircraft_id"`
+	AnomalyType    AnomalyType        `gorm:"type:varchar(32);not null" json:"anomaly_type"`
+	Status         AnomalyEventStatus `gorm:"type:varchar(16);index;not null" json:"status"`
+	Severity       Severity           `gorm:"type:varchar(16);not null" json:"severity"`
+	Violations     []Violation        `gorm:"type:jsonb;serializer:json" json:"violations,omitempty"` // Latest violations
+	FirstSeen      time.Time          `gorm:"type:timestamptz;not null" json:"first_seen"`
+	LastSeen       time.Time          `gorm:"type:timestamptz;not null" json:"last_seen"`
+	SampleCount    int64              `gorm:"not null;default:0" json:"sample_count"`
+	AcknowledgedAt *time.Time         `gorm:"type:timestamptz" json:"acknowledged_at,omitempty"`
+	AcknowledgedBy string             `json:"acknowledged_by,omitempty"`
+	ResolvedAt     *time.Time         `gorm:"type:timestamptz" json:"resolved_at,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+// TableName specifies the table name for AnomalyEvent
+func (AnomalyEvent) TableName() string {
+	return "anomaly_events"
+}
@@ -0,0 +1,16 @@
+package model
+
+// ControlType represents the kind of control message
+type ControlType string
+
+const (
+	ControlInvalidateThresholds ControlType = "invalidate_thresholds"
+	ControlInvalidateAircraft   ControlType = "invalidate_aircraft"
+)
+
+// ControlMessage is published on the control channel when data cached by the service changes
+type ControlMessage struct {
+	Type       ControlType `json:"type"`
+	AircraftID *uint       `json:"aircraft_id,omitempty"` // Affected aircraft, nil = all
+	MACAddress string      `json:"mac_address,omitempty"` // Affected aircraft MAC, empty = all
+}
@@ -1,19 +1,80 @@
 package model
 
 import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
 	"gorm.io/gorm"
 )
 
-// Geofence represents a restricted area (rectangle)
+// GeofenceShape represents the kind of area a geofence covers
+type GeofenceShape string
+
+const (
+	GeofenceShapeRectangle GeofenceShape = "rectangle"
+	GeofenceShapePolygon   GeofenceShape = "polygon"
+	GeofenceShapeCircle    GeofenceShape = "circle"
+)
+
+// RadiusUnit represents the unit of a circular geofence radius
+type RadiusUnit string
+
+const (
+	RadiusUnitMeters        RadiusUnit = "m"
+	RadiusUnitNauticalMiles RadiusUnit = "nm"
+)
+
+// AltitudeReference represents the datum of a geofence's altitude band
+type AltitudeReference string
+
+const (
+	AltitudeReferenceMSL AltitudeReference = "MSL" // Mean sea level
+	AltitudeReferenceAGL AltitudeReference = "AGL" // Above ground level
+)
+
+// Geofence represents a restricted area.
+// Rectangles are described by the min/max columns. Polygons are stored in Geometry as GeoJSON
+// (Polygon, MultiPolygon or Feature) or WKT (POLYGON, MULTIPOLYGON). Circles are a center and a
+// radius evaluated by great-circle distance. For polygons and circles the min/max columns hold
+// the bounding box. When an area crosses the antimeridian MinLongitude is greater than MaxLongitude.
+//
+// An optional altitude band (floor and/or ceiling, in the same unit as telemetry altitude) limits
+// the geofence vertically. AGL limits are converted to MSL using GroundElevation, the terrain
+// elevation of the area, since telemetry altitude is barometric.
+//
+// Activity can be limited to a time window and/or recurring schedule windows evaluated in
+// TimeZone; see IsActiveAt.
 type Geofence struct {
 	gorm.Model
-	Name         string  `gorm:"not null" json:"name"`
-	Description  string  `json:"description,omitempty"`
-	MinLatitude  float64 `gorm:"not null" json:"min_latitude"`
-	MaxLatitude  float64 `gorm:"not null" json:"max_latitude"`
-	MinLongitude float64 `gorm:"not null" json:"min_longitude"`
-	MaxLongitude float64 `gorm:"not null" json:"max_longitude"`
-	IsActive     bool    `gorm:"default:true" json:"is_active"`
+	Name              string            `gorm:"not null" json:"name"`
+	Description       string            `json:"description,omitempty"`
+	Shape             GeofenceShape     `gorm:"type:varchar(20);default:rectangle" json:"shape"`
+	Geometry          string            `gorm:"type:text" json:"geometry,omitempty"`
+	CenterLatitude    *float64          `json:"center_latitude,omitempty"`
+	CenterLongitude   *float64          `json:"center_longitude,omitempty"`
+	Radius            *float64          `json:"radius,omitempty"`
+	RadiusUnit        RadiusUnit        `gorm:"type:varchar(5)" json:"radius_unit,omitempty"` // m (default) or nm
+	MinLatitude       float64           `gorm:"not null" json:"min_latitude"`
+	MaxLatitude       float64           `gorm:"not null" json:"max_latitude"`
+	MinLongitude      float64           `gorm:"not null" json:"min_longitude"`
+	MaxLongitude      float64           `gorm:"not null" json:"max_longitude"`
+	MinAltitude       *float64          `json:"min_altitude,omitempty"` // Floor, nil = surface
+	MaxAltitude       *float64          `json:"max_altitude,omitempty"` // Ceiling, nil = unlimited
+	AltitudeReference AltitudeReference `gorm:"type:varchar(3);default:MSL" json:"altitude_reference,omitempty"`
+	GroundElevation   float64           `json:"ground_elevation,omitempty"` // Terrain elevation for AGL limits
+	IsActive          bool              `gorm:"default:true" json:"is_active"`
+	ActiveFrom        *time.Time        `gorm:"type:timestamptz" json:"active_from,omitempty"`  // nil = no start
+	ActiveUntil       *time.Time        `gorm:"type:timestamptz" json:"active_until,omitempty"` // nil = no end
+	Schedule          []ScheduleWindow  `gorm:"type:jsonb;serializer:json" json:"schedule,omitempty"`
+	TimeZone          string            `gorm:"type:varchar(64)" json:"time_zone,omitempty"` // IANA name, empty = UTC
+	MaxDwellSeconds   *int              `json:"max_dwell_seconds,omitempty"`                 // nil = service default
+
+	polygons    MultiPolygon   // Parsed Geometry
+	geometryErr error          // Error from parsing Geometry
+	location    *time.Location // Loaded TimeZone
+	locationErr error          // Error from loading TimeZone
 }
 
 // TableName specifies the table name for Geofence
@@ -21,11 +82,277 @@ func (Geofence) TableName() string {
 	return "geofences"
 }
 
-// ContainsPoint checks if a point (lat, lon) is inside the geofence rectangle
+// AfterFind hook parses the polygon geometry and time zone once when the geofence is loaded
+func (g *Geofence) AfterFind(tx *gorm.DB) error {
+	g.parseGeometry()
+	g.location, g.locationErr = loadTimeZone(g.TimeZone)
+	return nil
+}
+
+// Bounds returns the bounding box of the geofence area. When the area crosses the
+// antimeridian minLon is greater than maxLon.
+func (g *Geofence) Bounds() (minLat, maxLat, minLon, maxLon float64) {
+	switch g.Shape {
+	case GeofenceShapePolygon:
+		if g.GeometryError() == nil {
+			return g.polygons.Bounds()
+		}
+	case GeofenceShapeCircle:
+		if g.validateCircle() == nil {
+			return g.circleBounds()
+		}
+	}
+	return g.MinLatitude, g.MaxLatitude, g.MinLongitude, g.MaxLongitude
+}
+
+// BeforeSave hook validates the geometry and keeps the bounding box columns in sync with it
+func (g *Geofence) BeforeSave(tx *gorm.DB) error {
+	if g.Shape == "" {
+		g.Shape = GeofenceShapeRectangle
+	}
+
+	switch g.Shape {
+	case GeofenceShapePolygon:
+		g.parseGeometry()
+		if g.geometryErr != nil {
+			return fmt.Errorf("invalid geofence geometry: %w", g.geometryErr)
+		}
+		g.MinLatitude, g.MaxLatitude, g.MinLongitude, g.MaxLongitude = g.polygons.Bounds()
+	case GeofenceShapeCircle:
+		if err := g.validateCircle(); err != nil {
+			return fmt.Errorf("invalid circular geofence: %w", err)
+		}
+		g.MinLatitude, g.MaxLatitude, g.MinLongitude, g.MaxLongitude = g.circleBounds()
+	}
+
+	switch g.AltitudeReference {
+	case "":
+		g.AltitudeReference = AltitudeReferenceMSL
+	case AltitudeReferenceMSL, AltitudeReferenceAGL:
+	default:
+		return fmt.Errorf("unsupported altitude reference: %s", g.AltitudeReference)
+	}
+	if g.MinAltitude != nil && g.MaxAltitude != nil && *g.MinAltitude > *g.MaxAltitude {
+		return fmt.Errorf("geofence floor %f is above its ceiling %f", *g.MinAltitude, *g.MaxAltitude)
+	}
+
+	if err := g.validateSchedule(); err != nil {
+		return fmt.Errorf("invalid geofence schedule: %w", err)
+	}
+	return nil
+}
+
+// RadiusMeters returns the radius of a circular geofence in metres
+func (g *Geofence) RadiusMeters() float64 {
+	if g.Radius == nil {
+		return 0
+	}
+	if g.RadiusUnit == RadiusUnitNauticalMiles {
+		return *g.Radius * MetersPerNauticalMile
+	}
+	return *g.Radius
+}
+
+// validateCircle checks that a circular geofence has a valid center and radius
+func (g *Geofence) validateCircle() error {
+	if g.CenterLatitude == nil || g.CenterLongitude == nil {
+		return fmt.Errorf("center is required")
+	}
+	if *g.CenterLatitude < -90 || *g.CenterLatitude > 90 {
+		return fmt.Errorf("center latitude out of range: %f", *g.CenterLatitude)
+	}
+	if *g.CenterLongitude < -180 || *g.CenterLongitude > 180 {
+		return fmt.Errorf("center longitude out of range: %f", *g.CenterLongitude)
+	}
+	if g.Radius == nil || *g.Radius <= 0 {
+		return fmt.Errorf("radius must be positive")
+	}
+	switch g.RadiusUnit {
+	case "", RadiusUnitMeters, RadiusUnitNauticalMiles:
+	default:
+		return fmt.Errorf("unsupported radius unit: %s", g.RadiusUnit)
+	}
+	return nil
+}
+
+// circleBounds returns the bounding box of a circular geofence
+func (g *Geofence) circleBounds() (minLat, maxLat, minLon, maxLon float64) {
+	angular := g.RadiusMeters() / EarthRadiusMeters * 180 / math.Pi
+	minLat = math.Max(-90, *g.CenterLatitude-angular)
+	maxLat = math.Min(90, *g.CenterLatitude+angular)
+
+	// Circles reaching a pole cover every longitude
+	if minLat <= -90 || maxLat >= 90 {
+		return minLat, maxLat, -180, 180
+	}
+
+	dLon := math.Asin(math.Min(1, math.Sin(angular*math.Pi/180)/math.Cos(*g.CenterLatitude*math.Pi/180))) * 180 / math.Pi
+	if dLon >= 180 {
+		return minLat, maxLat, -180, 180
+	}
+	return minLat, maxLat, NormalizeLongitude(*g.CenterLongitude - dLon), NormalizeLongitude(*g.CenterLongitude + dLon)
+}
+
+// GeometryError returns the error encountered while parsing the polygon geometry, if any
+func (g *Geofence) GeometryError() error {
+	if g.Shape == GeofenceShapePolygon && g.polygons == nil && g.geometryErr == nil {
+		g.parseGeometry()
+	}
+	return g.geometryErr
+}
+
+// parseGeometry parses Geometry for polygon geofences
+func (g *Geofence) parseGeometry() {
+	g.polygons, g.geometryErr = nil, nil
+	if g.Shape != GeofenceShapePolygon {
+		return
+	}
+	g.polygons, g.geometryErr = ParseGeometry(g.Geometry)
+}
+
+// ContainsPosition checks if a position (lat, lon, altitude) is inside the geofence area and altitude band
+func (g *Geofence) ContainsPosition(lat, lon, altitude float64) bool {
+	return g.ContainsAltitude(altitude) && g.ContainsPoint(lat, lon)
+}
+
+// ContainsAltitude checks if an altitude is within the geofence's floor and ceiling
+func (g *Geofence) ContainsAltitude(altitude float64) bool {
+	offset := 0.0
+	if g.AltitudeReference == AltitudeReferenceAGL {
+		offset = g.GroundElevation
+	}
+	if g.MinAltitude != nil && altitude < *g.MinAltitude+offset {
+		return false
+	}
+	if g.MaxAltitude != nil && altitude > *g.MaxAltitude+offset {
+		return false
+	}
+	return true
+}
+
+// ContainsPoint checks if a point (lat, lon) is inside the geofence area, ignoring altitude
 func (g *Geofence) ContainsPoint(lat, lon float64) bool {
 	if !g.IsActive {
 		return false
 	}
-	return lat >= g.MinLatitude && lat <= g.MaxLatitude &&
-		lon >= g.MinLongitude && lon <= g.MaxLongitude
+
+	switch g.Shape {
+	case GeofenceShapePolygon:
+		if g.GeometryError() != nil {
+			return false
+		}
+		// The stored min/max columns are only filled on save, so test the parsed polygons directly
+		return g.polygons.Contains(lat, lon)
+	case GeofenceShapeCircle:
+		if g.CenterLatitude == nil || g.CenterLongitude == nil {
+			return false
+		}
+		return GreatCircleDistance(*g.CenterLatitude, *g.CenterLongitude, lat, lon) <= g.RadiusMeters()
+	default:
+		// Rows created before shapes were introduced have no shape and are rectangles
+		return g.inBoundingBox(lat, lon)
+	}
+}
+
+// inBoundingBox checks if a point is inside the min/max rectangle, which wraps across the
+// antimeridian when MinLongitude is greater than MaxLongitude
+func (g *Geofence) inBoundingBox(lat, lon float64) bool {
+	if lat < g.MinLatitude || lat > g.MaxLatitude {
+		return false
+	}
+	if g.MinLongitude <= g.MaxLongitude {
+		return lon >= g.MinLongitude && lon <= g.MaxLongitude
+	}
+	return lon >= g.MinLongitude || lon <= g.MaxLongitude
+}
+
+// SegmentEntry returns the fraction along the segment from (lat1, lon1) to (lat2, lon2) at which it first
+// reaches the geofence area, ignoring altitude. Unlike sampling points along the way, this finds areas
+// narrower than the segment. The segment is treated as a straight line in latitude and longitude (in a
+// local plane for circles), which holds for the short steps of a projected track.
+func (g *Geofence) SegmentEntry(lat1, lon1, lat2, lon2 float64) (float64, bool) {
+	if !g.IsActive {
+		return 0, false
+	}
+	if g.ContainsPoint(lat1, lon1) {
+		return 0, true
+	}
+
+	// Take the short way round, so segments crossing the antimeridian stay contiguous
+	dLon := NormalizeLongitude(lon2 - lon1)
+
+	var crossings []float64
+	switch g.Shape {
+	case GeofenceShapePolygon:
+		if g.GeometryError() != nil {
+			return 0, false
+		}
+		for _, polygon := range g.polygons {
+			for _, ring := range polygon {
+				crossings = append(crossings, ring.segmentCrossings(lat1, lon1, lat2, lon1+dLon)...)
+			}
+		}
+	case GeofenceShapeCircle:
+		if g.CenterLatitude == nil || g.CenterLongitude == nil {
+			return 0, false
+		}
+		return g.circleSegmentEntry(lat1, lon1, lat2, lon1+dLon)
+	default:
+		crossings = g.boundingBoxRing().segmentCrossings(lat1, lon1, lat2, lon1+dLon)
+	}
+
+	// The first crossing that leads into the area; others cross into holes or between parts
+	sort.Float64s(crossings)
+	for _, t := range crossings {
+		for _, at := range []float64{t, math.Min(1, t+1e-9)} {
+			if g.ContainsPoint(lat1+at*(lat2-lat1), NormalizeLongitude(lon1+at*dLon)) {
+				return t, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// circleSegmentEntry returns where a segment first comes within the radius of a circular geofence,
+// measured in an equirectangular plane centered on the circle
+func (g *Geofence) circleSegmentEntry(lat1, lon1, lat2, lon2 float64) (float64, bool) {
+	scale := EarthRadiusMeters * math.Pi / 180
+	cosLat := math.Cos(*g.CenterLatitude * math.Pi / 180)
+
+	x1 := NormalizeLongitude(lon1-*g.CenterLongitude) * cosLat * scale
+	y1 := (lat1 - *g.CenterLatitude) * scale
+	dx := (lon2 - lon1) * cosLat * scale
+	dy := (lat2 - lat1) * scale
+	radius := g.RadiusMeters()
+
+	// Solve |p1 + t*d| = radius for the smaller t
+	a := dx*dx + dy*dy
+	b := 2 * (x1*dx + y1*dy)
+	c := x1*x1 + y1*y1 - radius*radius
+	if c <= 0 {
+		return 0, true
+	}
+	discriminant := b*b - 4*a*c
+	if a == 0 || discriminant < 0 {
+		return 0, false
+	}
+	t := (-b - math.Sqrt(discriminant)) / (2 * a)
+	if t < 0 || t > 1 {
+		return 0, false
+	}
+	return t, true
+}
+
+// boundingBoxRing returns the min/max rectangle as a ring, unwrapped past 180 when it crosses the antimeridian
+func (g *Geofence) boundingBoxRing() Ring {
+	minLon, maxLon := g.MinLongitude, g.MaxLongitude
+	if minLon > maxLon {
+		maxLon += 360
+	}
+	return Ring{
+		{minLon, g.MinLatitude},
+		{maxLon, g.MinLatitude},
+		{maxLon, g.MaxLatitude},
+		{minLon, g.MaxLatitude},
+	}
 }
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+)
+
+// GeofenceEventType represents a transition of an aircraft relative to a geofence
+type GeofenceEventType string
+
+const (
+	GeofenceEventEntered       GeofenceEventType = "ENTERED"
+	GeofenceEventExited        GeofenceEventType = "EXITED"
+	GeofenceEventDwellExceeded GeofenceEventType = "DWELL_EXCEEDED"
+)
+
+// GeofenceEvent represents a single incursion event of an aircraft into a geofence
+type GeofenceEvent struct {
+	ID              uint              `gorm:"primaryKey" json:"id"`
+	AircraftID      uint              `gorm:"index:idx_geofence_events_aircraft_geofence;not null" json:"aircraft_id"`
+	GeofenceID      uint              `gorm:"index:idx_geofence_events_aircraft_geofence;not null" json:"geofence_id"`
+	GeofenceName    string            `json:"geofence_name"`
+	EventType       GeofenceEventType `gorm:"type:varchar(20);not null" json:"event_type"`
+	EnteredAt       time.Time         `gorm:"type:timestamptz;not null" json:"entered_at"`
+	OccurredAt      time.Time         `gorm:"type:timestamptz;not null;index" json:"occurred_at"` // Telemetry time of the event
+	DurationSeconds float64           `json:"duration_seconds"`                                   // Time inside the geofence so far
+	Latitude        float64           `json:"latitude"`
+	Longitude       float64           `json:"longitude"`
+	Altitude        float64           `json:"altitude"`
+	CreatedAt       time.Time         `json:"created_at"`
+}
+
+// TableName specifies the table name for GeofenceEvent
+func (GeofenceEvent) TableName() string {
+	return "geofence_events"
+}
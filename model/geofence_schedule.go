@@ -0,0 +1,166 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// weekdayNames maps schedule day names to weekdays
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ScheduleWindow is a recurring daily activity window in the geofence's time zone.
+// When End is earlier than Start the window runs overnight into the next day.
+type ScheduleWindow struct {
+	Days  []string `json:"days,omitempty"` // mon, tue, ..., sun; empty means every day
+	Start string   `json:"start"`          // HH:MM local time, inclusive
+	End   string   `json:"end"`            // HH:MM local time, exclusive
+}
+
+// IsActiveAt reports whether the geofence restriction applies at t. Besides the manual
+// IsActive flag, a geofence can be limited to a [ActiveFrom, ActiveUntil) window
+// (e.g. a temporary flight restriction) and to recurring schedule windows.
+func (g *Geofence) IsActiveAt(t time.Time) bool {
+	if !g.IsActive {
+		return false
+	}
+	if g.ActiveFrom != nil && t.Before(*g.ActiveFrom) {
+		return false
+	}
+	if g.ActiveUntil != nil && !t.Before(*g.ActiveUntil) {
+		return false
+	}
+	if len(g.Schedule) == 0 {
+		return true
+	}
+
+	if g.TimeZoneError() != nil {
+		return false
+	}
+
+	local := t.In(g.location)
+	for _, window := range g.Schedule {
+		if window.contains(local) {
+			return true
+		}
+	}
+	return false
+}
+
+// TimeZoneError returns the error encountered while loading the time zone, if any.
+// A scheduled geofence with an invalid time zone is never active.
+func (g *Geofence) TimeZoneError() error {
+	if g.location == nil && g.locationErr == nil {
+		g.location, g.locationErr = loadTimeZone(g.TimeZone)
+	}
+	return g.locationErr
+}
+
+// validateSchedule checks the activity window, schedule and time zone
+func (g *Geofence) validateSchedule() error {
+	if g.ActiveFrom != nil && g.ActiveUntil != nil && !g.ActiveFrom.Before(*g.ActiveUntil) {
+		return fmt.Errorf("active_from must be before active_until")
+	}
+
+	g.location, g.locationErr = loadTimeZone(g.TimeZone)
+	if g.locationErr != nil {
+		return g.locationErr
+	}
+
+	for _, window := range g.Schedule {
+		if err := window.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contains reports whether a local time falls inside the window
+func (w ScheduleWindow) contains(local time.Time) bool {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return false
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	if start <= end {
+		return w.onDay(local.Weekday()) && minute >= start && minute < end
+	}
+
+	// Overnight window: the late part belongs to today's window, the early part to yesterday's
+	if minute >= start {
+		return w.onDay(local.Weekday())
+	}
+	if minute < end {
+		return w.onDay((local.Weekday() + 6) % 7)
+	}
+	return false
+}
+
+// onDay reports whether the window applies on a weekday
+func (w ScheduleWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, name := range w.Days {
+		if weekday, ok := weekdayNames[strings.ToLower(name)]; ok && weekday == day {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks the window's days and clock times
+func (w ScheduleWindow) validate() error {
+	for _, name := range w.Days {
+		if _, ok := weekdayNames[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("invalid schedule day: %s", name)
+		}
+	}
+	if _, err := parseClock(w.Start); err != nil {
+		return err
+	}
+	if _, err := parseClock(w.End); err != nil {
+		return err
+	}
+	if w.Start == w.End {
+		return fmt.Errorf("schedule window start and end are equal: %s", w.Start)
+	}
+	return nil
+}
+
+// parseClock parses HH:MM into minutes since midnight; 24:00 is accepted as end of day
+func parseClock(clock string) (int, error) {
+	if clock == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schedule time %q, expected HH:MM", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// loadTimeZone loads an IANA time zone, defaulting to UTC
+func loadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", name, err)
+	}
+	return location, nil
+}
@@ -0,0 +1,152 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGeofenceIsActiveAt(t *testing.T) {
+	// 2026-03-02 is a Monday
+	monday := func(hour, minute int) time.Time {
+		return time.Date(2026, 3, 2, hour, minute, 0, 0, time.UTC)
+	}
+	from, until := monday(8, 0), monday(18, 0)
+
+	weekdays := []ScheduleWindow{{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "09:00", End: "17:00"}}
+	overnight := []ScheduleWindow{{Days: []string{"mon"}, Start: "22:00", End: "06:00"}}
+
+	tests := []struct {
+		name     string
+		geofence Geofence
+		at       time.Time
+		want     bool
+	}{
+		{"inactive", Geofence{IsActive: false}, monday(12, 0), false},
+		{"no schedule", Geofence{IsActive: true}, monday(12, 0), true},
+		{"before active from", Geofence{IsActive: true, ActiveFrom: &from}, monday(7, 59), false},
+		{"at active from", Geofence{IsActive: true, ActiveFrom: &from}, monday(8, 0), true},
+		{"before active until", Geofence{IsActive: true, ActiveUntil: &until}, monday(17, 59), true},
+		{"at active until", Geofence{IsActive: true, ActiveUntil: &until}, monday(18, 0), false},
+		{"window start is inclusive", Geofence{IsActive: true, Schedule: weekdays}, monday(9, 0), true},
+		{"window end is exclusive", Geofence{IsActive: true, Schedule: weekdays}, monday(17, 0), false},
+		{"outside window", Geofence{IsActive: true, Schedule: weekdays}, monday(8, 59), false},
+		{"weekend", Geofence{IsActive: true, Schedule: weekdays}, monday(12, 0).AddDate(0, 0, -1), false},
+		{"day names are case insensitive", Geofence{IsActive: true,
+			Schedule: []ScheduleWindow{{Days: []string{"MON"}, Start: "09:00", End: "17:00"}}}, monday(12, 0), true},
+		{"every day without days", Geofence{IsActive: true,
+			Schedule: []ScheduleWindow{{Start: "09:00", End: "17:00"}}}, monday(12, 0).AddDate(0, 0, 5), true},
+		{"until end of day", Geofence{IsActive: true,
+			Schedule: []ScheduleWindow{{Start: "20:00", End: "24:00"}}}, monday(23, 59), true},
+		{"overnight late part", Geofence{IsActive: true, Schedule: overnight}, monday(23, 0), true},
+		{"overnight early part on the next day", Geofence{IsActive: true, Schedule: overnight}, monday(5, 0).AddDate(0, 0, 1), true},
+		{"overnight early part of the window's own day", Geofence{IsActive: true, Schedule: overnight}, monday(5, 0), false},
+		{"overnight end is exclusive", Geofence{IsActive: true, Schedule: overnight}, monday(6, 0).AddDate(0, 0, 1), false},
+		{"overnight gap", Geofence{IsActive: true, Schedule: overnight}, monday(12, 0), false},
+		{"any of several windows", Geofence{IsActive: true, Schedule: []ScheduleWindow{
+			{Start: "06:00", End: "08:00"}, {Start: "18:00", End: "20:00"}}}, monday(19, 0), true},
+		// 12:00 UTC is 15:00 in Istanbul and 07:00 in New York
+		{"time zone ahead of UTC", Geofence{IsActive: true, TimeZone: "Europe/Istanbul",
+			Schedule: []ScheduleWindow{{Start: "14:00", End: "16:00"}}}, monday(12, 0), true},
+		{"time zone behind UTC", Geofence{IsActive: true, TimeZone: "America/New_York",
+			Schedule: []ScheduleWindow{{Start: "09:00", End: "17:00"}}}, monday(12, 0), false},
+		// 02:00 UTC on Monday is still Sunday evening in New York
+		{"time zone shifts the day", Geofence{IsActive: true, TimeZone: "America/New_York",
+			Schedule: []ScheduleWindow{{Days: []string{"sun"}, Start: "20:00", End: "23:00"}}}, monday(2, 0), true},
+		{"invalid time zone", Geofence{IsActive: true, TimeZone: "Mars/Olympus",
+			Schedule: []ScheduleWindow{{Start: "00:00", End: "24:00"}}}, monday(12, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.geofence.IsActiveAt(tt.at); got != tt.want {
+				t.Errorf("IsActiveAt(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeofenceIsActiveAtDaylightSaving(t *testing.T) {
+	// New York is UTC-5 before 2026-03-08 and UTC-4 after, so the same local window moves in UTC
+	geofence := Geofence{IsActive: true, TimeZone: "America/New_York",
+		Schedule: []ScheduleWindow{{Start: "09:00", End: "10:00"}}}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"standard time", time.Date(2026, 3, 6, 14, 30, 0, 0, time.UTC), true},
+		{"standard time hour ignored after change", time.Date(2026, 3, 9, 14, 30, 0, 0, time.UTC), false},
+		{"daylight time", time.Date(2026, 3, 9, 13, 30, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := geofence.IsActiveAt(tt.at); got != tt.want {
+				t.Errorf("IsActiveAt(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeofenceValidateSchedule(t *testing.T) {
+	from := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	before := from.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		geofence Geofence
+		wantErr  bool
+	}{
+		{"valid", Geofence{TimeZone: "Europe/Istanbul",
+			Schedule: []ScheduleWindow{{Days: []string{"mon"}, Start: "22:00", End: "06:00"}}}, false},
+		{"end of day", Geofence{Schedule: []ScheduleWindow{{Start: "20:00", End: "24:00"}}}, false},
+		{"active until before active from", Geofence{ActiveFrom: &from, ActiveUntil: &before}, true},
+		{"active until equal to active from", Geofence{ActiveFrom: &from, ActiveUntil: &from}, true},
+		{"invalid time zone", Geofence{TimeZone: "Mars/Olympus"}, true},
+		{"invalid day", Geofence{Schedule: []ScheduleWindow{{Days: []string{"funday"}, Start: "09:00", End: "17:00"}}}, true},
+		{"invalid clock", Geofence{Schedule: []ScheduleWindow{{Start: "9am", End: "17:00"}}}, true},
+		{"hour out of range", Geofence{Schedule: []ScheduleWindow{{Start: "09:00", End: "25:00"}}}, true},
+		{"empty window", Geofence{Schedule: []ScheduleWindow{{Start: "09:00", End: "09:00"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.geofence.validateSchedule()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGeofenceTimeZoneError(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeZone string
+		wantErr  bool
+	}{
+		{"empty is utc", "", false},
+		{"known zone", "Europe/Istanbul", false},
+		{"unknown zone", "Mars/Olympus", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geofence := &Geofence{IsActive: true, TimeZone: tt.timeZone,
+				Schedule: []ScheduleWindow{{Start: "00:00", End: "24:00"}}}
+			if err := geofence.AfterFind(nil); err != nil {
+				t.Fatalf("AfterFind() error = %v", err)
+			}
+
+			// Loading must not fail silently: the error is kept so the geofence service can report it
+			err := geofence.TimeZoneError()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TimeZoneError() = %v, wantErr %v", err, tt.wantErr)
+			}
+			if active := geofence.IsActiveAt(time.Now()); active == tt.wantErr {
+				t.Errorf("IsActiveAt() = %v with time zone error %v", active, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package model
+
+import (
+	"testing"
+)
+
+func circleGeofence(lat, lon, radius float64, unit RadiusUnit) *Geofence {
+	return &Geofence{
+		Shape:           GeofenceShapeCircle,
+		CenterLatitude:  &lat,
+		CenterLongitude: &lon,
+		Radius:          &radius,
+		RadiusUnit:      unit,
+		IsActive:        true,
+	}
+}
+
+func TestGeofenceContainsPointCircle(t *testing.T) {
+	// One degree of latitude is about 111.2 km
+	tests := []struct {
+		name     string
+		geofence *Geofence
+		lat, lon float64
+		want     bool
+	}{
+		{"centered on the pole, any longitude", circleGeofence(90, 0, 200000, ""), 88.5, 135, true},
+		{"centered on the pole, beyond radius", circleGeofence(90, 0, 200000, ""), 88, -45, false},
+		{"near the pole, across it", circleGeofence(89, 0, 200000, ""), 89.5, 180, true},
+		{"near the pole, across it beyond radius", circleGeofence(89, 0, 200000, ""), 89, 180, false},
+		{"south pole, across it", circleGeofence(-89.5, 90, 120000, ""), -89.5, -90, true},
+		{"antimeridian, east side", circleGeofence(0, 179.9, 50000, ""), 0, 179.5, true},
+		{"antimeridian, west side", circleGeofence(0, 179.9, 50000, ""), 0, -179.8, true},
+		{"antimeridian, beyond radius", circleGeofence(0, 179.9, 50000, ""), 0, -179.4, false},
+		{"nautical miles, inside", circleGeofence(0, 0, 10, RadiusUnitNauticalMiles), 0.16, 0, true},
+		{"nautical miles, outside", circleGeofence(0, 0, 10, RadiusUnitNauticalMiles), 0.17, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.geofence.ContainsPoint(tt.lat, tt.lon); got != tt.want {
+				t.Errorf("ContainsPoint(%v, %v) = %v, want %v", tt.lat, tt.lon, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeofenceBoundsCircle(t *testing.T) {
+	tests := []struct {
+		name         string
+		geofence     *Geofence
+		wantWrapped  bool // Bounding box crosses the antimeridian
+		wantAllLon   bool // Bounding box covers every longitude
+		lat, lon     float64
+		wantInBounds bool
+	}{
+		{"reaching the pole", circleGeofence(89, 0, 200000, ""), false, true, 89.5, 180, true},
+		{"crossing the antimeridian", circleGeofence(0, 179.9, 50000, ""), true, false, 0, -179.8, true},
+		{"crossing the antimeridian, outside", circleGeofence(0, 179.9, 50000, ""), true, false, 0, 179, false},
+		{"mid latitude", circleGeofence(45, 10, 50000, ""), false, false, 45, 10.6, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minLat, maxLat, minLon, maxLon := tt.geofence.Bounds()
+			if wrapped := minLon > maxLon; wrapped != tt.wantWrapped {
+				t.Errorf("Bounds() longitudes = (%v, %v), wrapped = %v, want %v", minLon, maxLon, wrapped, tt.wantWrapped)
+			}
+			if allLon := minLon == -180 && maxLon == 180; allLon != tt.wantAllLon {
+				t.Errorf("Bounds() longitudes = (%v, %v), all longitudes = %v, want %v", minLon, maxLon, allLon, tt.wantAllLon)
+			}
+
+			// The bounding box must contain the circle, which the spatial index relies on
+			box := &Geofence{MinLatitude: minLat, MaxLatitude: maxLat, MinLongitude: minLon, MaxLongitude: maxLon}
+			if got := box.inBoundingBox(tt.lat, tt.lon); got != tt.wantInBounds {
+				t.Errorf("bounding box contains (%v, %v) = %v, want %v", tt.lat, tt.lon, got, tt.wantInBounds)
+			}
+			if tt.wantInBounds && !tt.geofence.ContainsPoint(tt.lat, tt.lon) {
+				t.Errorf("ContainsPoint(%v, %v) = false, want a point inside the circle", tt.lat, tt.lon)
+			}
+		})
+	}
+}
+
+func TestGeofenceContainsAltitude(t *testing.T) {
+	floor, ceiling := 1000.0, 5000.0
+	tests := []struct {
+		name      string
+		reference AltitudeReference
+		ground    float64
+		min, max  *float64
+		altitude  float64
+		want      bool
+	}{
+		{"msl inside band", AltitudeReferenceMSL, 0, &floor, &ceiling, 3000, true},
+		{"msl at floor", AltitudeReferenceMSL, 0, &floor, &ceiling, 1000, true},
+		{"msl at ceiling", AltitudeReferenceMSL, 0, &floor, &ceiling, 5000, true},
+		{"msl below floor", AltitudeReferenceMSL, 0, &floor, &ceiling, 999, false},
+		{"msl above ceiling", AltitudeReferenceMSL, 0, &floor, &ceiling, 5001, false},
+		{"msl ignores ground elevation", AltitudeReferenceMSL, 2000, &floor, &ceiling, 1500, true},
+		{"empty reference is msl", "", 2000, &floor, &ceiling, 1500, true},
+		{"agl inside band", AltitudeReferenceAGL, 2000, &floor, &ceiling, 4000, true},
+		{"agl below floor over high ground", AltitudeReferenceAGL, 2000, &floor, &ceiling, 2500, false},
+		{"agl at floor", AltitudeReferenceAGL, 2000, &floor, &ceiling, 3000, true},
+		{"agl above ceiling", AltitudeReferenceAGL, 2000, &floor, &ceiling, 7001, false},
+		{"agl at ceiling", AltitudeReferenceAGL, 2000, &floor, &ceiling, 7000, true},
+		{"no floor", AltitudeReferenceAGL, 2000, nil, &ceiling, 0, true},
+		{"no ceiling", AltitudeReferenceMSL, 0, &floor, nil, 60000, true},
+		{"unbounded", AltitudeReferenceMSL, 0, nil, nil, -100, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geofence := &Geofence{
+				AltitudeReference: tt.reference,
+				GroundElevation:   tt.ground,
+				MinAltitude:       tt.min,
+				MaxAltitude:       tt.max,
+			}
+			if got := geofence.ContainsAltitude(tt.altitude); got != tt.want {
+				t.Errorf("ContainsAltitude(%v) = %v, want %v", tt.altitude, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeofenceBeforeSaveAltitude(t *testing.T) {
+	low, high := 1000.0, 5000.0
+	tests := []struct {
+		name          string
+		reference     AltitudeReference
+		min, max      *float64
+		wantReference AltitudeReference
+		wantErr       bool
+	}{
+		{"defaults to msl", "", &low, &high, AltitudeReferenceMSL, false},
+		{"keeps agl", AltitudeReferenceAGL, &low, &high, AltitudeReferenceAGL, false},
+		{"unknown reference", "QNH", &low, &high, "", true},
+		{"floor above ceiling", AltitudeReferenceMSL, &high, &low, "", true},
+		{"floor only", AltitudeReferenceMSL, &high, nil, AltitudeReferenceMSL, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geofence := &Geofence{
+				Shape:             GeofenceShapeRectangle,
+				AltitudeReference: tt.reference,
+				MinAltitude:       tt.min,
+				MaxAltitude:       tt.max,
+			}
+			err := geofence.BeforeSave(nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BeforeSave() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && geofence.AltitudeReference != tt.wantReference {
+				t.Errorf("AltitudeReference = %q, want %q", geofence.AltitudeReference, tt.wantReference)
+			}
+		})
+	}
+}
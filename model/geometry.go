@@ -0,0 +1,507 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Point is a (longitude, latitude) pair in degrees, in GeoJSON coordinate order
+type Point [2]float64
+
+// Ring is a closed sequence of points. Longitudes are unwrapped on parse so that
+// consecutive vertices never differ by more than 180 degrees, which keeps rings that
+// cross the antimeridian contiguous (e.g. 179, 181 instead of 179, -179).
+type Ring []Point
+
+// Polygon is an outer ring followed by zero or more holes
+type Polygon []Ring
+
+// MultiPolygon is a set of polygons
+type MultiPolygon []Polygon
+
+// ParseGeometry parses a GeoJSON (Polygon, MultiPolygon or a Feature wrapping one) or
+// WKT (POLYGON, MULTIPOLYGON, optionally prefixed with SRID=n;) geometry
+func ParseGeometry(geometry string) (MultiPolygon, error) {
+	geometry = strings.TrimSpace(geometry)
+	if geometry == "" {
+		return nil, fmt.Errorf("geometry is empty")
+	}
+
+	var multiPolygon MultiPolygon
+	var err error
+	if strings.HasPrefix(geometry, "{") {
+		multiPolygon, err = parseGeoJSON([]byte(geometry))
+	} else {
+		multiPolygon, err = parseWKT(geometry)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := multiPolygon.validate(); err != nil {
+		return nil, err
+	}
+
+	for _, polygon := range multiPolygon {
+		for i := range polygon {
+			polygon[i] = polygon[i].unwrap()
+		}
+	}
+
+	return multiPolygon, nil
+}
+
+// Contains reports whether a point (lat, lon) is inside any polygon. Points on an edge count as inside.
+func (m MultiPolygon) Contains(lat, lon float64) bool {
+	for _, polygon := range m {
+		if polygon.contains(lat, lon) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bounds returns the bounding box of the outer rings. When the geometry crosses the
+// antimeridian minLon is greater than maxLon.
+func (m MultiPolygon) Bounds() (minLat, maxLat, minLon, maxLon float64) {
+	minLat, maxLat = math.Inf(1), math.Inf(-1)
+	minLon, maxLon = math.Inf(1), math.Inf(-1)
+	for _, polygon := range m {
+		if len(polygon) == 0 {
+			continue
+		}
+		for _, p := range polygon[0] {
+			minLon = math.Min(minLon, p[0])
+			maxLon = math.Max(maxLon, p[0])
+			minLat = math.Min(minLat, p[1])
+			maxLat = math.Max(maxLat, p[1])
+		}
+	}
+
+	if maxLon-minLon >= 360 {
+		return minLat, maxLat, -180, 180
+	}
+	return minLat, maxLat, NormalizeLongitude(minLon), NormalizeLongitude(maxLon)
+}
+
+// EarthRadiusMeters is the mean Earth radius used for great-circle calculations
+const EarthRadiusMeters = 6371008.8
+
+// MetersPerNauticalMile is the length of an international nautical mile
+const MetersPerNauticalMile = 1852.0
+
+// GreatCircleDistance returns the haversine distance in metres between two points given in degrees
+func GreatCircleDistance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * EarthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Destination returns the point reached by travelling distance metres from (lat, lon) along the
+// given initial bearing in degrees, following a great circle
+func Destination(lat, lon, bearing, distance float64) (float64, float64) {
+	phi1 := lat * math.Pi / 180
+	lambda1 := lon * math.Pi / 180
+	theta := bearing * math.Pi / 180
+	delta := distance / EarthRadiusMeters
+
+	phi2 := math.Asin(math.Sin(phi1)*math.Cos(delta) + math.Cos(phi1)*math.Sin(delta)*math.Cos(theta))
+	lambda2 := lambda1 + math.Atan2(
+		math.Sin(theta)*math.Sin(delta)*math.Cos(phi1),
+		math.Cos(delta)-math.Sin(phi1)*math.Sin(phi2),
+	)
+
+	return phi2 * 180 / math.Pi, NormalizeLongitude(lambda2 * 180 / math.Pi)
+}
+
+// NormalizeLongitude maps a longitude into [-180, 180]
+func NormalizeLongitude(lon float64) float64 {
+	for lon > 180 {
+		lon -= 360
+	}
+	for lon < -180 {
+		lon += 360
+	}
+	return lon
+}
+
+// contains reports whether a point is inside the outer ring and outside every hole
+func (p Polygon) contains(lat, lon float64) bool {
+	if len(p) == 0 || !p[0].contains(lat, lon) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if hole.containsStrict(lat, lon) {
+			return false
+		}
+	}
+	return true
+}
+
+// contains tests the point against the ring, including its boundary. The point is tried
+// at lon and lon±360 so that it matches rings unwrapped past ±180.
+func (r Ring) contains(lat, lon float64) bool {
+	for _, offset := range []float64{0, 360, -360} {
+		if r.onBoundary(lat, lon+offset) || r.pointInRing(lat, lon+offset) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsStrict tests the point against the ring, excluding its boundary
+func (r Ring) containsStrict(lat, lon float64) bool {
+	for _, offset := range []float64{0, 360, -360} {
+		if !r.onBoundary(lat, lon+offset) && r.pointInRing(lat, lon+offset) {
+			return true
+		}
+	}
+	return false
+}
+
+// pointInRing implements the even-odd ray casting rule
+func (r Ring) pointInRing(lat, lon float64) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xi, yi := r[i][0], r[i][1]
+		xj, yj := r[j][0], r[j][1]
+		if (yi > lat) != (yj > lat) {
+			x := xi + (lat-yi)*(xj-xi)/(yj-yi)
+			if lon < x {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// onBoundary reports whether the point lies on one of the ring's edges
+func (r Ring) onBoundary(lat, lon float64) bool {
+	const epsilon = 1e-12
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xi, yi := r[i][0], r[i][1]
+		xj, yj := r[j][0], r[j][1]
+		cross := (lon-xi)*(yj-yi) - (lat-yi)*(xj-xi)
+		if math.Abs(cross) > epsilon {
+			continue
+		}
+		if lon >= math.Min(xi, xj)-epsilon && lon <= math.Max(xi, xj)+epsilon &&
+			lat >= math.Min(yi, yj)-epsilon && lat <= math.Max(yi, yj)+epsilon {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentCrossings returns the fractions along the segment from (lat1, lon1) to (lat2, lon2) at which it
+// crosses the ring's edges. Edges are tried at lon and lon±360 to match rings unwrapped past ±180.
+func (r Ring) segmentCrossings(lat1, lon1, lat2, lon2 float64) []float64 {
+	dx, dy := lon2-lon1, lat2-lat1
+
+	var crossings []float64
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		ex, ey := r[i][0]-r[j][0], r[i][1]-r[j][1]
+		denominator := dx*ey - dy*ex
+		if denominator == 0 {
+			// Parallel; a collinear overlap is found through the segment's end points
+			continue
+		}
+		for _, offset := range []float64{0, 360, -360} {
+			qx, qy := r[j][0]+offset-lon1, r[j][1]-lat1
+			t := (qx*ey - qy*ex) / denominator
+			u := (qx*dy - qy*dx) / denominator
+			if t >= 0 && t <= 1 && u >= 0 && u <= 1 {
+				crossings = append(crossings, t)
+			}
+		}
+	}
+	return crossings
+}
+
+// unwrap returns a copy of the ring with longitudes adjusted so consecutive vertices
+// differ by at most 180 degrees
+func (r Ring) unwrap() Ring {
+	unwrapped := make(Ring, len(r))
+	copy(unwrapped, r)
+	for i := 1; i < len(unwrapped); i++ {
+		for unwrapped[i][0]-unwrapped[i-1][0] > 180 {
+			unwrapped[i][0] -= 360
+		}
+		for unwrapped[i][0]-unwrapped[i-1][0] < -180 {
+			unwrapped[i][0] += 360
+		}
+	}
+	return unwrapped
+}
+
+// validate checks ring sizes and coordinate ranges
+func (m MultiPolygon) validate() error {
+	if len(m) == 0 {
+		return fmt.Errorf("geometry has no polygons")
+	}
+	for _, polygon := range m {
+		if len(polygon) == 0 {
+			return fmt.Errorf("polygon has no rings")
+		}
+		for _, ring := range polygon {
+			if len(ring) < 3 {
+				return fmt.Errorf("ring must have at least 3 points, got %d", len(ring))
+			}
+			for _, p := range ring {
+				if p[1] < -90 || p[1] > 90 {
+					return fmt.Errorf("latitude out of range: %f", p[1])
+				}
+				if p[0] < -360 || p[0] > 360 {
+					return fmt.Errorf("longitude out of range: %f", p[0])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// geoJSONGeometry is the subset of a GeoJSON object needed to read polygon geometries
+type geoJSONGeometry struct {
+	Type        string           `json:"type"`
+	Coordinates json.RawMessage  `json:"coordinates"`
+	Geometry    *geoJSONGeometry `json:"geometry"`
+}
+
+// parseGeoJSON parses a GeoJSON Polygon, MultiPolygon or Feature
+func parseGeoJSON(data []byte) (MultiPolygon, error) {
+	var geometry geoJSONGeometry
+	if err := json.Unmarshal(data, &geometry); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+
+	if geometry.Type == "Feature" {
+		if geometry.Geometry == nil {
+			return nil, fmt.Errorf("GeoJSON feature has no geometry")
+		}
+		geometry = *geometry.Geometry
+	}
+
+	switch geometry.Type {
+	case "Polygon":
+		var coordinates [][][]float64
+		if err := json.Unmarshal(geometry.Coordinates, &coordinates); err != nil {
+			return nil, fmt.Errorf("invalid GeoJSON polygon coordinates: %w", err)
+		}
+		polygon, err := toPolygon(coordinates)
+		if err != nil {
+			return nil, err
+		}
+		return MultiPolygon{polygon}, nil
+	case "MultiPolygon":
+		var coordinates [][][][]float64
+		if err := json.Unmarshal(geometry.Coordinates, &coordinates); err != nil {
+			return nil, fmt.Errorf("invalid GeoJSON multipolygon coordinates: %w", err)
+		}
+		multiPolygon := make(MultiPolygon, 0, len(coordinates))
+		for _, c := range coordinates {
+			polygon, err := toPolygon(c)
+			if err != nil {
+				return nil, err
+			}
+			multiPolygon = append(multiPolygon, polygon)
+		}
+		return multiPolygon, nil
+	default:
+		return nil, fmt.Errorf("unsupported GeoJSON geometry type: %s", geometry.Type)
+	}
+}
+
+// toPolygon converts GeoJSON polygon coordinates, ignoring any altitude component
+func toPolygon(coordinates [][][]float64) (Polygon, error) {
+	polygon := make(Polygon, 0, len(coordinates))
+	for _, ringCoordinates := range coordinates {
+		ring := make(Ring, 0, len(ringCoordinates))
+		for _, c := range ringCoordinates {
+			if len(c) < 2 {
+				return nil, fmt.Errorf("GeoJSON position must have at least 2 values")
+			}
+			ring = append(ring, Point{c[0], c[1]})
+		}
+		polygon = append(polygon, ring)
+	}
+	return polygon, nil
+}
+
+// parseWKT parses a WKT POLYGON or MULTIPOLYGON
+func parseWKT(wkt string) (MultiPolygon, error) {
+	// Strip EWKT SRID prefix
+	if i := strings.Index(wkt, ";"); i >= 0 && strings.HasPrefix(strings.ToUpper(wkt), "SRID=") {
+		wkt = wkt[i+1:]
+	}
+
+	upper := strings.ToUpper(strings.TrimSpace(wkt))
+	var geometryType string
+	switch {
+	case strings.HasPrefix(upper, "MULTIPOLYGON"):
+		geometryType = "MULTIPOLYGON"
+	case strings.HasPrefix(upper, "POLYGON"):
+		geometryType = "POLYGON"
+	default:
+		return nil, fmt.Errorf("unsupported WKT geometry: %.20s", wkt)
+	}
+
+	p := &wktParser{s: strings.TrimSpace(wkt)[len(geometryType):]}
+	p.skipSpaces()
+	// Ignore Z/M/ZM dimension markers
+	for _, marker := range []string{"ZM", "Z", "M"} {
+		if strings.HasPrefix(strings.ToUpper(p.s[p.pos:]), marker) {
+			p.pos += len(marker)
+			break
+		}
+	}
+
+	var multiPolygon MultiPolygon
+	if geometryType == "POLYGON" {
+		polygon, err := p.polygon()
+		if err != nil {
+			return nil, err
+		}
+		multiPolygon = MultiPolygon{polygon}
+	} else {
+		var err error
+		multiPolygon, err = p.multiPolygon()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	p.skipSpaces()
+	if p.pos != len(p.s) {
+		return nil, fmt.Errorf("unexpected trailing WKT: %q", p.s[p.pos:])
+	}
+
+	return multiPolygon, nil
+}
+
+// wktParser is a small recursive descent parser for WKT polygon bodies
+type wktParser struct {
+	s   string
+	pos int
+}
+
+func (p *wktParser) skipSpaces() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n' || p.s[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+// consume skips spaces and consumes c if it is the next character
+func (p *wktParser) consume(c byte) bool {
+	p.skipSpaces()
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *wktParser) expect(c byte) error {
+	if !p.consume(c) {
+		return fmt.Errorf("invalid WKT: expected %q at position %d", c, p.pos)
+	}
+	return nil
+}
+
+func (p *wktParser) number() (float64, error) {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("+-.0123456789eE", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	value, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid WKT number at position %d", start)
+	}
+	return value, nil
+}
+
+// ring parses "(x y[ z[ m]], ...)"
+func (p *wktParser) ring() (Ring, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	var ring Ring
+	for {
+		lon, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		lat, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		// Skip optional Z and M values
+		p.skipSpaces()
+		for p.pos < len(p.s) && p.s[p.pos] != ',' && p.s[p.pos] != ')' {
+			if _, err := p.number(); err != nil {
+				return nil, err
+			}
+			p.skipSpaces()
+		}
+		ring = append(ring, Point{lon, lat})
+
+		if p.consume(')') {
+			return ring, nil
+		}
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// polygon parses "(ring, ring, ...)"
+func (p *wktParser) polygon() (Polygon, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	var polygon Polygon
+	for {
+		ring, err := p.ring()
+		if err != nil {
+			return nil, err
+		}
+		polygon = append(polygon, ring)
+
+		if p.consume(')') {
+			return polygon, nil
+		}
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// multiPolygon parses "(polygon, polygon, ...)"
+func (p *wktParser) multiPolygon() (MultiPolygon, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	var multiPolygon MultiPolygon
+	for {
+		polygon, err := p.polygon()
+		if err != nil {
+			return nil, err
+		}
+		multiPolygon = append(multiPolygon, polygon)
+
+		if p.consume(')') {
+			return multiPolygon, nil
+		}
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+	}
+}
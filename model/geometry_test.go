@@ -0,0 +1,147 @@
+package model
+
+import (
+	"testing"
+)
+
+// Squares used across the tests: 10..20 in both axes, with a 14..16 hole, and a square
+// crossing the antimeridian between 170E and 170W
+const (
+	squareWKT       = "POLYGON ((10 10, 20 10, 20 20, 10 20, 10 10))"
+	squareHoleWKT   = "POLYGON ((10 10, 20 10, 20 20, 10 20, 10 10), (14 14, 16 14, 16 16, 14 16, 14 14))"
+	antimeridianWKT = "POLYGON ((170 -10, -170 -10, -170 10, 170 10, 170 -10))"
+)
+
+func TestParseGeometry(t *testing.T) {
+	tests := []struct {
+		name     string
+		geometry string
+		polygons int
+		rings    int // Rings of the first polygon
+		wantErr  bool
+	}{
+		{"wkt polygon", squareWKT, 1, 1, false},
+		{"wkt polygon with hole", squareHoleWKT, 1, 2, false},
+		{"wkt lower case", "polygon((10 10, 20 10, 20 20, 10 10))", 1, 1, false},
+		{"wkt with srid", "SRID=4326;" + squareWKT, 1, 1, false},
+		{"wkt with z values", "POLYGON Z ((10 10 5, 20 10 5, 20 20 5, 10 10 5))", 1, 1, false},
+		{"wkt multipolygon", "MULTIPOLYGON (((10 10, 20 10, 20 20, 10 10)), ((30 30, 40 30, 40 40, 30 30)))", 2, 1, false},
+		{"geojson polygon", `{"type":"Polygon","coordinates":[[[10,10],[20,10],[20,20],[10,20],[10,10]]]}`, 1, 1, false},
+		{"geojson with altitude", `{"type":"Polygon","coordinates":[[[10,10,1],[20,10,1],[20,20,1],[10,10,1]]]}`, 1, 1, false},
+		{"geojson multipolygon", `{"type":"MultiPolygon","coordinates":[[[[10,10],[20,10],[20,20],[10,10]]],[[[30,30],[40,30],[40,40],[30,30]]]]}`, 2, 1, false},
+		{"geojson feature", `{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[10,10],[20,10],[20,20],[10,10]]]}}`, 1, 1, false},
+		{"empty", "  ", 0, 0, true},
+		{"unsupported wkt", "POINT (10 10)", 0, 0, true},
+		{"unsupported geojson", `{"type":"Point","coordinates":[10,10]}`, 0, 0, true},
+		{"feature without geometry", `{"type":"Feature"}`, 0, 0, true},
+		{"invalid json", `{"type":`, 0, 0, true},
+		{"too few points", "POLYGON ((10 10, 20 10))", 0, 0, true},
+		{"latitude out of range", "POLYGON ((10 10, 20 10, 20 95, 10 10))", 0, 0, true},
+		{"unbalanced parentheses", "POLYGON ((10 10, 20 10, 20 20, 10 10)", 0, 0, true},
+		{"trailing text", squareWKT + " extra", 0, 0, true},
+		{"bad number", "POLYGON ((10 10, 20 x, 20 20, 10 10))", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseGeometry(tt.geometry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseGeometry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(got) != tt.polygons {
+				t.Fatalf("ParseGeometry() polygons = %d, want %d", len(got), tt.polygons)
+			}
+			if len(got[0]) != tt.rings {
+				t.Errorf("ParseGeometry() rings = %d, want %d", len(got[0]), tt.rings)
+			}
+		})
+	}
+}
+
+func TestMultiPolygonContains(t *testing.T) {
+	tests := []struct {
+		name     string
+		geometry string
+		lat, lon float64
+		want     bool
+	}{
+		{"inside", squareWKT, 15, 15, true},
+		{"outside", squareWKT, 25, 15, false},
+		{"on edge", squareWKT, 10, 15, true},
+		{"on vertex", squareWKT, 20, 20, true},
+		{"inside hole", squareHoleWKT, 15, 15, false},
+		{"on hole edge", squareHoleWKT, 14, 15, true},
+		{"between outer ring and hole", squareHoleWKT, 12, 12, true},
+		{"antimeridian east side", antimeridianWKT, 0, 175, true},
+		{"antimeridian west side", antimeridianWKT, 0, -175, true},
+		{"antimeridian on the line", antimeridianWKT, 0, 180, true},
+		{"antimeridian on the line negative", antimeridianWKT, 0, -180, true},
+		{"antimeridian outside east", antimeridianWKT, 0, 165, false},
+		{"antimeridian outside west", antimeridianWKT, 0, -165, false},
+		{"antimeridian far side of the globe", antimeridianWKT, 0, 0, false},
+		{"antimeridian outside latitude", antimeridianWKT, 15, 180, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			polygons, err := ParseGeometry(tt.geometry)
+			if err != nil {
+				t.Fatalf("ParseGeometry() error = %v", err)
+			}
+			if got := polygons.Contains(tt.lat, tt.lon); got != tt.want {
+				t.Errorf("Contains(%v, %v) = %v, want %v", tt.lat, tt.lon, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiPolygonBounds(t *testing.T) {
+	tests := []struct {
+		name                           string
+		geometry                       string
+		minLat, maxLat, minLon, maxLon float64
+	}{
+		{"square", squareWKT, 10, 20, 10, 20},
+		{"antimeridian", antimeridianWKT, -10, 10, 170, -170},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			polygons, err := ParseGeometry(tt.geometry)
+			if err != nil {
+				t.Fatalf("ParseGeometry() error = %v", err)
+			}
+			minLat, maxLat, minLon, maxLon := polygons.Bounds()
+			if minLat != tt.minLat || maxLat != tt.maxLat || minLon != tt.minLon || maxLon != tt.maxLon {
+				t.Errorf("Bounds() = (%v, %v, %v, %v), want (%v, %v, %v, %v)",
+					minLat, maxLat, minLon, maxLon, tt.minLat, tt.maxLat, tt.minLon, tt.maxLon)
+			}
+		})
+	}
+}
+
+func TestGeofenceContainsPointPolygon(t *testing.T) {
+	// Geofences loaded without their bounding box columns must still match on the polygon itself
+	geofence := &Geofence{Shape: GeofenceShapePolygon, Geometry: antimeridianWKT, IsActive: true}
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     bool
+	}{
+		{"east of the antimeridian", 5, 179, true},
+		{"west of the antimeridian", -5, -179, true},
+		{"outside", 5, 160, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := geofence.ContainsPoint(tt.lat, tt.lon); got != tt.want {
+				t.Errorf("ContainsPoint(%v, %v) = %v, want %v", tt.lat, tt.lon, got, tt.want)
+			}
+		})
+	}
+}
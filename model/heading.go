@@ -0,0 +1,48 @@
+package model
+
+import "math"
+
+// HeadingDelta returns the shortest turn in degrees from one heading to another, in (-180, 180];
+// positive is clockwise
+func HeadingDelta(from, to float64) float64 {
+	delta := math.Mod(to-from, 360)
+	if delta <= -180 {
+		delta += 360
+	} else if delta > 180 {
+		delta -= 360
+	}
+	return delta
+}
+
+// NormalizeHeading maps a heading into [0, 360)
+func NormalizeHeading(heading float64) float64 {
+	heading = math.Mod(heading, 360)
+	if heading < 0 {
+		heading += 360
+	}
+	return heading
+}
+
+// HeadingSector is the range of headings clockwise from Start to End, which may wrap through north
+// (e.g. 350 to 10). Start equal to End is a single heading; 0 to 360 is every heading.
+type HeadingSector struct {
+	Start float64
+	End   float64
+}
+
+// Contains reports whether a heading is within the sector, edges included
+func (s HeadingSector) Contains(heading float64) bool {
+	if math.Abs(s.End-s.Start) >= 360 {
+		return true
+	}
+	return NormalizeHeading(heading-s.Start) <= NormalizeHeading(s.End-s.Start)
+}
+
+// Outside returns how many degrees a heading is clockwise past End and counter-clockwise before Start.
+// Both are zero for headings within the sector.
+func (s HeadingSector) Outside(heading float64) (pastEnd, beforeStart float64) {
+	if s.Contains(heading) {
+		return 0, 0
+	}
+	return NormalizeHeading(heading - s.End), NormalizeHeading(s.Start - heading)
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// QuarantinedTelemetry holds telemetry that was received but kept out of telemetry_data,
+// for example from retired aircraft
+type QuarantinedTelemetry struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	AircraftID  uint      `gorm:"uniqueIndex:idx_quarantined_telemetry_aircraft_time;not null" json:"aircraft_id"`
+	Time        time.Time `gorm:"uniqueIndex:idx_quarantined_telemetry_aircraft_time;type:timestamptz(6);not null" json:"time"`
+	Latitude    float64   `json:"latitude"`
+	Longitude   float64   `json:"longitude"`
+	Altitude    float64   `json:"altitude"`
+	GroundSpeed float64   `json:"ground_speed"`
+	Heading     float64   `json:"heading"`
+	ClimbRate   float64   `json:"climb_rate"`
+	Reason      string    `gorm:"not null" json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for QuarantinedTelemetry
+func (QuarantinedTelemetry) TableName() string {
+	return "quarantined_telemetry"
+}
+
+// BeforeCreate hook to set CreatedAt
+func (q *QuarantinedTelemetry) BeforeCreate(tx *gorm.DB) error {
+	if q.CreatedAt.IsZero() {
+		q.CreatedAt = time.Now()
+	}
+	return nil
+}
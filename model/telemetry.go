@@ -6,20 +6,22 @@ import (
 	"gorm.io/gorm"
 )
 
-// Telemetry represents processed telemetry data stored in TimescaleDB
+// Telemetry represents processed telemetry data stored in TimescaleDB.
+// Rows are keyed by (aircraft_id, time) so redelivered stream entries map to the same row.
 type Telemetry struct {
-	Time        time.Time `gorm:"primaryKey;type:timestamptz;not null" json:"time"`
-	AircraftID  uint      `gorm:"index;not null" json:"aircraft_id"`
-	Latitude    float64   `json:"latitude"`
-	Longitude   float64   `json:"longitude"`
-	Altitude    float64   `json:"altitude"`
-	GroundSpeed float64   `json:"ground_speed"`
-	Heading     float64   `json:"heading"`
-	ClimbRate   float64   `json:"climb_rate"`
-	Temperature *float64  `json:"temperature,omitempty"` // Optional, for future use
-	HasAnomaly  bool      `gorm:"default:false" json:"has_anomaly"`
-	AnomalyType string    `json:"anomaly_type,omitempty"` // threshold, geofence, both
-	CreatedAt   time.Time `json:"created_at"`
+	AircraftID  uint        `gorm:"primaryKey;autoIncrement:false;index;not null" json:"aircraft_id"`
+	Time        time.Time   `gorm:"primaryKey;type:timestamptz(6);not null" json:"time"` // Microsecond precision
+	Latitude    float64     `json:"latitude"`
+	Longitude   float64     `json:"longitude"`
+	Altitude    float64     `json:"altitude"`
+	GroundSpeed float64     `json:"ground_speed"`
+	Heading     float64     `json:"heading"`
+	ClimbRate   float64     `json:"climb_rate"`
+	Temperature *float64    `json:"temperature,omitempty"` // Optional, for future use
+	HasAnomaly  bool        `gorm:"default:false" json:"has_anomaly"`
+	AnomalyType string      `json:"anomaly_type,omitempty"` // threshold, geofence, both, approaching_geofence, unauthorized_movement
+	Violations  []Violation `gorm:"type:jsonb;serializer:json" json:"violations,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
 }
 
 // TableName specifies the table name for Telemetry
@@ -1,14 +1,40 @@
 package model
 
+import (
+	"fmt"
+	"time"
+)
+
 // TelemetryDTO defines the structure for incoming telemetry data from ingestion service
 type TelemetryDTO struct {
-	Timestamp   uint64  `json:"timestamp"`  // time of the telemetry data
-	PlaneID     string  `json:"planeId"`    // unique identifier for the plane (MAC address)
-	Latitude    float64 `json:"lat"`        // latitude of the plane
-	Longitude   float64 `json:"lon"`        // longitude of the plane
-	Altitude    float64 `json:"alt_baro"`   // barometric altitude
-	GroundSpeed float64 `json:"gs"`         // ground speed
-	Heading     float64 `json:"heading"`    // heading of the plane
-	ClimbRate   float64 `json:"climb_rate"` // climb rate of the plane
+	Timestamp   Timestamp `json:"timestamp"`  // time of the telemetry data
+	PlaneID     string    `json:"planeId"`    // unique identifier for the plane (MAC address)
+	Latitude    float64   `json:"lat"`        // latitude of the plane
+	Longitude   float64   `json:"lon"`        // longitude of the plane
+	Altitude    float64   `json:"alt_baro"`   // barometric altitude
+	GroundSpeed float64   `json:"gs"`         // ground speed
+	Heading     float64   `json:"heading"`    // heading of the plane
+	ClimbRate   float64   `json:"climb_rate"` // climb rate of the plane
 }
 
+// ResolveTimestamp replaces a missing timestamp with receivedAt and rejects timestamps that are
+// more than maxFutureSkew ahead of or maxPastAge behind receivedAt. A zero limit disables that check;
+// the stream consumer always passes positive limits, using its defaults for unset ones.
+func (d *TelemetryDTO) ResolveTimestamp(receivedAt time.Time, maxFutureSkew, maxPastAge time.Duration) error {
+	if d.Timestamp.IsZero() {
+		d.Timestamp = Timestamp{Time: receivedAt}
+		return nil
+	}
+
+	if maxFutureSkew > 0 && d.Timestamp.After(receivedAt.Add(maxFutureSkew)) {
+		return fmt.Errorf("timestamp %s is more than %s ahead of receive time %s",
+			d.Timestamp.Format(time.RFC3339Nano), maxFutureSkew, receivedAt.Format(time.RFC3339Nano))
+	}
+
+	if maxPastAge > 0 && d.Timestamp.Before(receivedAt.Add(-maxPastAge)) {
+		return fmt.Errorf("timestamp %s is more than %s behind receive time %s",
+			d.Timestamp.Format(time.RFC3339Nano), maxPastAge, receivedAt.Format(time.RFC3339Nano))
+	}
+
+	return nil
+}
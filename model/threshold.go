@@ -1,6 +1,9 @@
 package model
 
 import (
+	"fmt"
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -23,9 +26,183 @@ type Threshold struct {
 	MaxValue   *float64 `json:"max_value,omitempty"`
 	MinValue   *float64 `json:"min_value,omitempty"`
 	IsDefault  bool     `gorm:"default:false" json:"is_default"`
+
+	// How far past MinValue/MaxValue a value must be for a warning or critical violation.
+	// Violations short of WarningBand are info; without a warning band they are warnings.
+	WarningBand  *float64 `json:"warning_band,omitempty"`
+	CriticalBand *float64 `json:"critical_band,omitempty"`
+
+	// Hysteresis: once raised, a violation stays raised until the value is back within the clear level.
+	// nil uses MaxValue/MinValue.
+	ClearMaxValue *float64 `json:"clear_max_value,omitempty"`
+	ClearMinValue *float64 `json:"clear_min_value,omitempty"`
+
+	// A breach of MinValue/MaxValue or the heading sector is raised only after it has lasted SustainSeconds
+	// and SustainSamples consecutive samples. nil or zero disables that condition. Rate breaches are raised
+	// on the first breaching sample, since a rate already spans two samples.
+	SustainSeconds *int `json:"sustain_seconds,omitempty"`
+	SustainSamples *int `json:"sustain_samples,omitempty"`
+
+	// Rate-of-change limits in metric units per second, measured against the previous sample of the
+	// same aircraft. Heading changes are the shortest turn, positive clockwise. Drops use a negative MinRate.
+	MaxRate *float64 `json:"max_rate,omitempty"`
+	MinRate *float64 `json:"min_rate,omitempty"`
+
+	// How far past MinRate/MaxRate a rate must be for a warning or critical violation, in units per second.
+	// Graded like WarningBand/CriticalBand.
+	RateWarningBand  *float64 `json:"rate_warning_band,omitempty"`
+	RateCriticalBand *float64 `json:"rate_critical_band,omitempty"`
+
+	// Allowed headings for the heading metric, clockwise from SectorStart to SectorEnd; the sector may
+	// wrap through north. Without a sector MinValue/MaxValue are read as its start and end, and for
+	// heading ClearMinValue/ClearMaxValue are the start and end of the clear sector.
+	SectorStart *float64 `json:"sector_start,omitempty"`
+	SectorEnd   *float64 `json:"sector_end,omitempty"`
 }
 
 // TableName specifies the table name for Threshold
 func (Threshold) TableName() string {
 	return "thresholds"
 }
+
+// SeverityFor returns the severity of a value violation that is excess past the limit
+func (t *Threshold) SeverityFor(excess float64) Severity {
+	return severityFor(excess, t.WarningBand, t.CriticalBand)
+}
+
+// RateSeverityFor returns the severity of a rate violation that is excess per second past the rate limit
+func (t *Threshold) RateSeverityFor(excess float64) Severity {
+	return severityFor(excess, t.RateWarningBand, t.RateCriticalBand)
+}
+
+// severityFor grades excess past a limit with optional warning and critical bands
+func severityFor(excess float64, warningBand, criticalBand *float64) Severity {
+	if criticalBand != nil && excess >= *criticalBand {
+		return SeverityCritical
+	}
+	if warningBand != nil {
+		if excess >= *warningBand {
+			return SeverityWarning
+		}
+		return SeverityInfo
+	}
+	return SeverityWarning
+}
+
+// Sector returns the allowed heading sector of a heading threshold.
+// Returns false if the threshold does not limit the heading itself.
+func (t *Threshold) Sector() (HeadingSector, bool) {
+	if t.MetricName != string(MetricHeading) {
+		return HeadingSector{}, false
+	}
+	if t.SectorStart != nil && t.SectorEnd != nil {
+		return HeadingSector{Start: *t.SectorStart, End: *t.SectorEnd}, true
+	}
+	if t.MinValue == nil && t.MaxValue == nil {
+		return HeadingSector{}, false
+	}
+
+	sector := HeadingSector{Start: 0, End: 360}
+	if t.MinValue != nil {
+		sector.Start = *t.MinValue
+	}
+	if t.MaxValue != nil {
+		sector.End = *t.MaxValue
+	}
+	return sector, true
+}
+
+// ClearSector returns the sector a raised heading violation must return into to clear
+func (t *Threshold) ClearSector() HeadingSector {
+	sector, _ := t.Sector()
+	if t.ClearMinValue != nil {
+		sector.Start = *t.ClearMinValue
+	}
+	if t.ClearMaxValue != nil {
+		sector.End = *t.ClearMaxValue
+	}
+	return sector
+}
+
+// BeforeSave validates clear levels, sustain conditions, rate limits and heading sectors
+func (t *Threshold) BeforeSave(tx *gorm.DB) error {
+	if err := t.validateLevels(); err != nil {
+		return err
+	}
+	if t.SustainSeconds != nil && *t.SustainSeconds < 0 {
+		return fmt.Errorf("sustain_seconds must not be negative")
+	}
+	if t.SustainSamples != nil && *t.SustainSamples < 0 {
+		return fmt.Errorf("sustain_samples must not be negative")
+	}
+	if t.MaxRate != nil && t.MinRate != nil && *t.MinRate > *t.MaxRate {
+		return fmt.Errorf("min_rate %.2f must not be above max_rate %.2f", *t.MinRate, *t.MaxRate)
+	}
+	return nil
+}
+
+// validateLevels checks that clear levels lie within the limits, circularly for heading
+func (t *Threshold) validateLevels() error {
+	if t.MetricName == string(MetricHeading) {
+		return t.validateSector()
+	}
+	if t.SectorStart != nil || t.SectorEnd != nil {
+		return fmt.Errorf("sector_start and sector_end are only supported for %s", MetricHeading)
+	}
+
+	if t.ClearMaxValue != nil {
+		if t.MaxValue == nil {
+			return fmt.Errorf("clear_max_value requires max_value")
+		}
+		if *t.ClearMaxValue > *t.MaxValue {
+			return fmt.Errorf("clear_max_value %.2f must not be above max_value %.2f", *t.ClearMaxValue, *t.MaxValue)
+		}
+	}
+	if t.ClearMinValue != nil {
+		if t.MinValue == nil {
+			return fmt.Errorf("clear_min_value requires min_value")
+		}
+		if *t.ClearMinValue < *t.MinValue {
+			return fmt.Errorf("clear_min_value %.2f must not be below min_value %.2f", *t.ClearMinValue, *t.MinValue)
+		}
+	}
+	return nil
+}
+
+// validateSector checks a heading threshold's sector and clear sector
+func (t *Threshold) validateSector() error {
+	if (t.SectorStart == nil) != (t.SectorEnd == nil) {
+		return fmt.Errorf("sector_start and sector_end must be set together")
+	}
+	for _, bearing := range []*float64{t.SectorStart, t.SectorEnd, t.MinValue, t.MaxValue, t.ClearMinValue, t.ClearMaxValue} {
+		if bearing != nil && (*bearing < 0 || *bearing > 360) {
+			return fmt.Errorf("heading limits must be between 0 and 360, got %.2f", *bearing)
+		}
+	}
+
+	sector, ok := t.Sector()
+	if !ok {
+		if t.ClearMinValue != nil || t.ClearMaxValue != nil {
+			return fmt.Errorf("clear_min_value and clear_max_value require a heading sector")
+		}
+		return nil
+	}
+	for _, bearing := range []*float64{t.ClearMinValue, t.ClearMaxValue} {
+		if bearing != nil && !sector.Contains(*bearing) {
+			return fmt.Errorf("clear heading %.2f must be within the sector %.2f to %.2f", *bearing, sector.Start, sector.End)
+		}
+	}
+	return nil
+}
+
+// IsSustained reports whether a breach that started at since and has lasted samples consecutive
+// samples up to now meets the sustain conditions
+func (t *Threshold) IsSustained(since, now time.Time, samples int) bool {
+	if t.SustainSeconds != nil && now.Sub(since) < time.Duration(*t.SustainSeconds)*time.Second {
+		return false
+	}
+	if t.SustainSamples != nil && samples < *t.SustainSamples {
+		return false
+	}
+	return true
+}
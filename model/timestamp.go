@@ -0,0 +1,113 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Epoch magnitude boundaries used to detect the unit of numeric timestamps.
+// Seconds stay below 1e11 until the year 5138, so anything larger is a finer unit.
+const (
+	maxEpochSeconds      = 1e11
+	maxEpochMilliseconds = 1e14
+	maxEpochMicroseconds = 1e17
+)
+
+// Timestamp is a telemetry timestamp that accepts Unix seconds, milliseconds, microseconds or
+// nanoseconds (detected by magnitude, fractional values allowed) as well as RFC3339 strings.
+// A missing, null or zero timestamp unmarshals to the zero time.
+type Timestamp struct {
+	time.Time
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	if data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("invalid timestamp: %w", err)
+		}
+		return t.parseString(s)
+	}
+
+	return t.parseNumber(string(data))
+}
+
+// parseString parses an RFC3339 timestamp or a quoted epoch number
+func (t *Timestamp) parseString(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		t.Time = parsed.UTC()
+		return nil
+	}
+
+	return t.parseNumber(s)
+}
+
+// parseNumber parses an epoch number, detecting its unit by magnitude
+func (t *Timestamp) parseNumber(s string) error {
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("invalid timestamp: %q", s)
+	}
+	if value < 0 {
+		return fmt.Errorf("invalid timestamp: negative epoch %q", s)
+	}
+	if value == 0 {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	if nanos, ok := epochNanos(s, value); ok {
+		t.Time = time.Unix(0, nanos).UTC()
+		return nil
+	}
+
+	return fmt.Errorf("invalid timestamp: %q out of range", s)
+}
+
+// epochNanos converts an epoch value of unknown unit to nanoseconds.
+// The conversion uses exact rational arithmetic, float64 loses precision at nanosecond scale.
+func epochNanos(s string, value float64) (int64, bool) {
+	var unit int64
+	switch {
+	case value < maxEpochSeconds:
+		unit = int64(time.Second)
+	case value < maxEpochMilliseconds:
+		unit = int64(time.Millisecond)
+	case value < maxEpochMicroseconds:
+		unit = int64(time.Microsecond)
+	default:
+		unit = int64(time.Nanosecond)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, false
+	}
+	r.Mul(r, new(big.Rat).SetInt64(unit))
+
+	// Sub-nanosecond fractions are truncated
+	nanos := new(big.Int).Quo(r.Num(), r.Denom())
+	if !nanos.IsInt64() {
+		return 0, false
+	}
+	return nanos.Int64(), true
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimestampUnmarshalJSON(t *testing.T) {
+	want := time.Date(2026, 3, 1, 12, 30, 45, 0, time.UTC)
+	tests := []struct {
+		name    string
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"seconds", `1772368245`, want, false},
+		{"fractional seconds", `1772368245.5`, want.Add(500 * time.Millisecond), false},
+		{"milliseconds", `1772368245123`, want.Add(123 * time.Millisecond), false},
+		{"microseconds", `1772368245123456`, want.Add(123456 * time.Microsecond), false},
+		{"nanoseconds", `1772368245123456789`, want.Add(123456789 * time.Nanosecond), false},
+		{"quoted number", `"1772368245"`, want, false},
+		{"rfc3339", `"2026-03-01T12:30:45Z"`, want, false},
+		{"rfc3339 with offset", `"2026-03-01T15:30:45+03:00"`, want, false},
+		{"rfc3339 nanoseconds", `"2026-03-01T12:30:45.000000001Z"`, want.Add(time.Nanosecond), false},
+		{"null", `null`, time.Time{}, false},
+		{"zero", `0`, time.Time{}, false},
+		{"empty string", `""`, time.Time{}, false},
+		{"negative", `-1`, time.Time{}, true},
+		{"not a number", `"yesterday"`, time.Time{}, true},
+		{"boolean", `true`, time.Time{}, true},
+		{"out of range", `1e30`, time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ts Timestamp
+			err := json.Unmarshal([]byte(tt.input), &ts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !ts.Time.Equal(tt.want) {
+				t.Errorf("Unmarshal(%s) = %v, want %v", tt.input, ts.Time, tt.want)
+			}
+			if !ts.Time.IsZero() && ts.Time.Location() != time.UTC {
+				t.Errorf("Unmarshal(%s) location = %v, want UTC", tt.input, ts.Time.Location())
+			}
+		})
+	}
+}
+
+func TestTimestampMissingField(t *testing.T) {
+	var dto struct {
+		Timestamp Timestamp `json:"timestamp"`
+	}
+	if err := json.Unmarshal([]byte(`{}`), &dto); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !dto.Timestamp.IsZero() {
+		t.Errorf("missing timestamp = %v, want zero time", dto.Timestamp.Time)
+	}
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+)
+
+// UnknownAircraftSighting aggregates telemetry received for a MAC address that has no registered aircraft
+type UnknownAircraftSighting struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	MACAddress    string    `gorm:"uniqueIndex;not null" json:"mac_address"`
+	FirstSeen     time.Time `gorm:"type:timestamptz;not null" json:"first_seen"`
+	LastSeen      time.Time `gorm:"type:timestamptz;not null" json:"last_seen"`
+	MessageCount  int64     `gorm:"not null;default:0" json:"message_count"` // Approximate, redelivered entries are counted again
+	LastLatitude  float64   `json:"last_latitude"`
+	LastLongitude float64   `json:"last_longitude"`
+	LastAltitude  float64   `json:"last_altitude"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for UnknownAircraftSighting
+func (UnknownAircraftSighting) TableName() string {
+	return "unknown_aircraft_sightings"
+}
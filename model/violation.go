@@ -0,0 +1,101 @@
+package model
+
+import (
+	"strings"
+)
+
+// ViolationKind represents what kind of rule a violation broke
+type ViolationKind string
+
+const (
+	ViolationKindUnauthorizedMovement ViolationKind = "unauthorized_movement"
+	ViolationKindThreshold            ViolationKind = "threshold"
+	ViolationKindGeofence             ViolationKind = "geofence"
+	ViolationKindApproachingGeofence  ViolationKind = "approaching_geofence"
+)
+
+// Severity represents how serious a violation or incident is
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// severityLevels lists severities from least to most serious
+var severityLevels = []Severity{SeverityInfo, SeverityWarning, SeverityCritical}
+
+// Rank returns the position of the severity in increasing seriousness; unknown severities rank lowest
+func (s Severity) Rank() int {
+	for i, level := range severityLevels {
+		if level == s {
+			return i
+		}
+	}
+	return 0
+}
+
+// Escalate returns the severity raised by the given number of levels, capped at critical.
+// Negative levels lower the severity, down to info.
+func (s Severity) Escalate(levels int) Severity {
+	rank := s.Rank() + levels
+	if rank >= len(severityLevels) {
+		rank = len(severityLevels) - 1
+	}
+	if rank < 0 {
+		rank = 0
+	}
+	return severityLevels[rank]
+}
+
+// MaxSeverity returns the most serious severity among the violations, or info if there are none
+func MaxSeverity(violations []Violation) Severity {
+	severity := SeverityInfo
+	for _, violation := range violations {
+		if violation.Severity.Rank() > severity.Rank() {
+			severity = violation.Severity
+		}
+	}
+	return severity
+}
+
+// BoundType represents which side of a threshold was crossed
+type BoundType string
+
+const (
+	BoundTypeMax BoundType = "max"
+	BoundTypeMin BoundType = "min"
+
+	// Rate-of-change limits; the observed value and limit are per second
+	BoundTypeMaxRate BoundType = "max_rate"
+	BoundTypeMinRate BoundType = "min_rate"
+)
+
+// IsRate reports whether the bound limits a rate of change
+func (b BoundType) IsRate() bool {
+	return b == BoundTypeMaxRate || b == BoundTypeMinRate
+}
+
+// Violation is a single broken rule behind an anomaly
+type Violation struct {
+	Kind               ViolationKind `json:"kind"`
+	Severity           Severity      `json:"severity"`
+	Metric             string        `json:"metric,omitempty"`
+	Observed           *float64      `json:"observed,omitempty"`
+	Limit              *float64      `json:"limit,omitempty"`
+	Bound              BoundType     `json:"bound,omitempty"`
+	GeofenceID         *uint         `json:"geofence_id,omitempty"`
+	GeofenceName       string        `json:"geofence_name,omitempty"`
+	TimeToEntrySeconds *float64      `json:"time_to_entry_seconds,omitempty"` // Approaching geofence only
+	Message            string        `json:"message"`                         // Human-readable description
+}
+
+// JoinViolationMessages joins the messages of the violations in order
+func JoinViolationMessages(violations []Violation) string {
+	messages := make([]string, len(violations))
+	for i, violation := range violations {
+		messages[i] = violation.Message
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestSeverityEscalate(t *testing.T) {
+	tests := []struct {
+		name     string
+		severity Severity
+		levels   int
+		want     Severity
+	}{
+		{"no change", SeverityWarning, 0, SeverityWarning},
+		{"one level", SeverityInfo, 1, SeverityWarning},
+		{"two levels", SeverityInfo, 2, SeverityCritical},
+		{"capped at critical", SeverityWarning, 5, SeverityCritical},
+		{"negative lowers", SeverityCritical, -1, SeverityWarning},
+		{"negative capped at info", SeverityWarning, -3, SeverityInfo},
+		{"unknown ranks as info", Severity("bogus"), 1, SeverityWarning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.severity.Escalate(tt.levels); got != tt.want {
+				t.Errorf("%q.Escalate(%d) = %q, want %q", tt.severity, tt.levels, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxSeverity(t *testing.T) {
+	tests := []struct {
+		name       string
+		violations []Violation
+		want       Severity
+	}{
+		{"none", nil, SeverityInfo},
+		{"single", []Violation{{Severity: SeverityWarning}}, SeverityWarning},
+		{"highest wins", []Violation{{Severity: SeverityWarning}, {Severity: SeverityCritical}, {Severity: SeverityInfo}}, SeverityCritical},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaxSeverity(tt.violations); got != tt.want {
+				t.Errorf("MaxSeverity() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
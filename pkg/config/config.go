@@ -16,23 +16,54 @@ const configFilePath = "./configs/appconfig.%s.json"
 
 // Config is a struct that contains the config for the application.
 type Config struct {
-	AppName               string `json:"app_name"`
-	Port                  string `json:"port"`
-	SwaggerEnabled        bool   `json:"swagger_enabled"`
-	LogLevel              string `json:"log_level"`
-	RedisAddr             string `json:"redis_addr"`
-	RedisPassword         string `json:"redis_password"`
-	RedisStreamKey        string `json:"redis_stream_key"`
-	RedisConsumerGroup    string `json:"redis_consumer_group"`
-	RedisPubSubGlobalFeed string `json:"redis_pubsub_global_feed"`
-	RedisPubSubAlertFeed  string `json:"redis_pubsub_alert_feed"`
-	PostgresHost          string `json:"postgres_host"`
-	PostgresPort          string `json:"postgres_port"`
-	PostgresUser          string `json:"postgres_user"`
-	PostgresPassword      string `json:"postgres_password"`
-	PostgresDb            string `json:"postgres_db"`
-	PostgresSSLMode       string `json:"postgres_sslmode"`
-	AutoMigrate           bool   `json:"auto_migrate"`
+	AppName                  string  `json:"app_name"`
+	Port                     string  `json:"port"`
+	SwaggerEnabled           bool    `json:"swagger_enabled"`
+	LogLevel                 string  `json:"log_level"`
+	RedisAddr                string  `json:"redis_addr"`
+	RedisPassword            string  `json:"redis_password"`
+	RedisStreamKey           string  `json:"redis_stream_key"`
+	RedisConsumerGroup       string  `json:"redis_consumer_group"`
+	RedisPubSubGlobalFeed    string  `json:"redis_pubsub_global_feed"`
+	RedisPubSubAlertFeed     string  `json:"redis_pubsub_alert_feed"`
+	RedisPubSubUnknown       string  `json:"redis_pubsub_unknown_aircraft"`
+	RedisPubSubControl       string  `json:"redis_pubsub_control_channel"` // Cache invalidation messages
+	RedisReclaimInterval     int     `json:"redis_reclaim_interval_seconds"`
+	RedisReclaimMinIdle      int     `json:"redis_reclaim_min_idle_seconds"`
+	RedisReclaimBatchSize    int64   `json:"redis_reclaim_batch_size"`
+	RedisMaxDeliveries       int64   `json:"redis_max_deliveries"`
+	RedisDLQStreamKey        string  `json:"redis_dlq_stream_key"`
+	WorkerLanes              int     `json:"worker_lanes"`
+	WorkerLaneBufferSize     int     `json:"worker_lane_buffer_size"`
+	TelemetryWriteBackend    string  `json:"telemetry_write_backend"`           // gorm or copy
+	TelemetryMaxSkew         int     `json:"telemetry_max_future_skew_seconds"` // 0 = 300
+	TelemetryMaxAge          int     `json:"telemetry_max_past_age_seconds"`    // 0 = 86400
+	TelemetryBatchSize       int     `json:"telemetry_batch_size"`
+	TelemetryBatchLatency    int     `json:"telemetry_batch_max_latency_ms"`
+	GroundedMovementSpeed    float64 `json:"grounded_movement_speed"` // Ground speed above which a grounded aircraft is moving
+	AircraftAutoRegister     bool    `json:"aircraft_auto_register"`  // Register unknown MACs under AircraftDefaultOwner
+	AircraftDefaultOwner     uint    `json:"aircraft_default_owner_id"`
+	AircraftCacheTTL         int     `json:"aircraft_cache_ttl_seconds"`
+	AircraftCacheNegativeTTL int     `json:"aircraft_cache_negative_ttl_seconds"` // How long unknown MACs are remembered
+	AnomalyEscalateAfter     int     `json:"anomaly_escalation_seconds"`          // 0 = no escalation
+	ThresholdRefreshInterval int     `json:"threshold_refresh_interval_seconds"`  // Full reload in case a control message is missed
+	GeofenceRefreshInterval  int     `json:"geofence_refresh_interval_seconds"`
+	GeofenceMaxDwell         int     `json:"geofence_max_dwell_seconds"` // 0 = no dwell events unless set per geofence
+	GeofenceLookAhead        int     `json:"geofence_lookahead_seconds"` // 0 = no approach prediction
+	AdminEnabled             bool    `json:"admin_enabled"`
+	AdminAddr                string  `json:"admin_addr"`  // Internal listen address of the admin endpoints
+	AdminToken               string  `json:"admin_token"` // Bearer token required by the admin endpoints
+	PostgresHost             string  `json:"postgres_host"`
+	PostgresPort             string  `json:"postgres_port"`
+	PostgresUser             string  `json:"postgres_user"`
+	PostgresPassword         string  `json:"postgres_password"`
+	PostgresDb               string  `json:"postgres_db"`
+	PostgresSSLMode          string  `json:"postgres_sslmode"`
+	AutoMigrate              bool    `json:"auto_migrate"`
+	TimescaleChunkHours      int     `json:"timescale_chunk_interval_hours"`
+	TimescalePartitions      int     `json:"timescale_space_partitions"`
+	TimescaleCompressDays    int     `json:"timescale_compress_after_days"`
+	TimescaleRetainDays      int     `json:"timescale_retention_days"`
 }
 
 // Load is a function that loads the config from the file.
@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/logging"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// migrationLockID is the advisory lock key that serializes migrations across instances
+const migrationLockID = 7202604
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrate applies the SQL migrations in migrations/ that have not run yet, in file name order,
+// and then sets up the telemetry hypertable. Both run in one transaction holding the migration
+// advisory lock, so instances starting together wait for each other instead of racing on DDL.
+// Unlike AutoMigrate it runs on every start, so the schema this service depends on exists in
+// every environment.
+func Migrate(db *gorm.DB, timescaleConfig TimescaleConfig) error {
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+	sort.Strings(names)
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Another instance may be migrating; wait for it and then re-check
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+			return fmt.Errorf("failed to lock migrations: %w", err)
+		}
+
+		if err := tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    text PRIMARY KEY,
+			applied_at timestamptz NOT NULL DEFAULT now()
+		)`).Error; err != nil {
+			return fmt.Errorf("failed to create schema_migrations: %w", err)
+		}
+
+		for _, name := range names {
+			if err := applyMigration(tx, name); err != nil {
+				return err
+			}
+		}
+
+		return setupTimescale(tx, timescaleConfig)
+	})
+}
+
+// applyMigration runs one migration file unless it was already applied
+func applyMigration(tx *gorm.DB, name string) error {
+	version := name[len("migrations/"):]
+
+	script, err := migrationFiles.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("failed to read migration %s: %w", version, err)
+	}
+
+	var applied bool
+	if err := tx.Raw("SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = ?)", version).Scan(&applied).Error; err != nil {
+		return fmt.Errorf("failed to check migration %s: %w", version, err)
+	}
+	if applied {
+		return nil
+	}
+
+	logging.Info("Applying database migration", zap.String("version", version))
+
+	if err := tx.Exec(string(script)).Error; err != nil {
+		return fmt.Errorf("failed to apply migration %s: %w", version, err)
+	}
+	if err := tx.Exec("INSERT INTO schema_migrations (version) VALUES (?)", version).Error; err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", version, err)
+	}
+	return nil
+}
+
+// execOptional runs a statement that may fail without aborting the surrounding transaction
+func execOptional(tx *gorm.DB, savepoint, sql string, values ...interface{}) error {
+	if err := tx.SavePoint(savepoint).Error; err != nil {
+		return fmt.Errorf("failed to create savepoint %s: %w", savepoint, err)
+	}
+
+	if err := tx.Exec(sql, values...).Error; err != nil {
+		if rollbackErr := tx.RollbackTo(savepoint).Error; rollbackErr != nil {
+			return fmt.Errorf("failed to roll back to savepoint %s: %w", savepoint, rollbackErr)
+		}
+		return err
+	}
+	return nil
+}
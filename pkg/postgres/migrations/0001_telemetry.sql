@@ -0,0 +1,22 @@
+-- Telemetry rows, keyed by aircraft and time so redelivered entries are written once.
+-- Existing tables keep their columns; the primary key is migrated by 0005.
+CREATE TABLE IF NOT EXISTS telemetry_data (
+    aircraft_id  bigint         NOT NULL,
+    time         timestamptz(6) NOT NULL,
+    latitude     numeric,
+    longitude    numeric,
+    altitude     numeric,
+    ground_speed numeric,
+    heading      numeric,
+    climb_rate   numeric,
+    temperature  numeric,
+    has_anomaly  boolean DEFAULT false,
+    anomaly_type text,
+    violations   jsonb,
+    created_at   timestamptz,
+    PRIMARY KEY (aircraft_id, time)
+);
+
+ALTER TABLE telemetry_data ADD COLUMN IF NOT EXISTS violations jsonb;
+
+CREATE INDEX IF NOT EXISTS idx_telemetry_data_aircraft_id ON telemetry_data (aircraft_id);
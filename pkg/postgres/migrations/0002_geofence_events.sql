@@ -0,0 +1,37 @@
+-- Geofence shape, altitude, schedule and dwell settings read by the geofence service.
+-- The geofences table itself is managed outside this service.
+ALTER TABLE IF EXISTS geofences
+    ADD COLUMN IF NOT EXISTS shape              varchar(20) DEFAULT 'rectangle',
+    ADD COLUMN IF NOT EXISTS geometry           text,
+    ADD COLUMN IF NOT EXISTS center_latitude    numeric,
+    ADD COLUMN IF NOT EXISTS center_longitude   numeric,
+    ADD COLUMN IF NOT EXISTS radius             numeric,
+    ADD COLUMN IF NOT EXISTS radius_unit        varchar(5),
+    ADD COLUMN IF NOT EXISTS min_altitude       numeric,
+    ADD COLUMN IF NOT EXISTS max_altitude       numeric,
+    ADD COLUMN IF NOT EXISTS altitude_reference varchar(3) DEFAULT 'MSL',
+    ADD COLUMN IF NOT EXISTS ground_elevation   numeric,
+    ADD COLUMN IF NOT EXISTS active_from        timestamptz,
+    ADD COLUMN IF NOT EXISTS active_until       timestamptz,
+    ADD COLUMN IF NOT EXISTS schedule           jsonb,
+    ADD COLUMN IF NOT EXISTS time_zone          varchar(64),
+    ADD COLUMN IF NOT EXISTS max_dwell_seconds  bigint;
+
+-- Entry, exit and dwell events of aircraft in geofences
+CREATE TABLE IF NOT EXISTS geofence_events (
+    id               bigserial PRIMARY KEY,
+    aircraft_id      bigint      NOT NULL,
+    geofence_id      bigint      NOT NULL,
+    geofence_name    text,
+    event_type       varchar(20) NOT NULL,
+    entered_at       timestamptz NOT NULL,
+    occurred_at      timestamptz NOT NULL,
+    duration_seconds numeric,
+    latitude         numeric,
+    longitude        numeric,
+    altitude         numeric,
+    created_at       timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_geofence_events_aircraft_geofence ON geofence_events (aircraft_id, geofence_id);
+CREATE INDEX IF NOT EXISTS idx_geofence_events_occurred_at ON geofence_events (occurred_at);
@@ -0,0 +1,67 @@
+-- Severity, hysteresis, sustain, rate and heading sector settings read by the threshold service.
+-- The thresholds table itself is managed outside this service.
+ALTER TABLE IF EXISTS thresholds
+    ADD COLUMN IF NOT EXISTS warning_band    numeric,
+    ADD COLUMN IF NOT EXISTS critical_band   numeric,
+    ADD COLUMN IF NOT EXISTS clear_max_value numeric,
+    ADD COLUMN IF NOT EXISTS clear_min_value numeric,
+    ADD COLUMN IF NOT EXISTS sustain_seconds bigint,
+    ADD COLUMN IF NOT EXISTS sustain_samples bigint,
+    ADD COLUMN IF NOT EXISTS max_rate        numeric,
+    ADD COLUMN IF NOT EXISTS min_rate        numeric,
+    ADD COLUMN IF NOT EXISTS sector_start    numeric,
+    ADD COLUMN IF NOT EXISTS sector_end      numeric;
+
+-- Anomaly incidents with their open, acknowledged and resolved lifecycle
+CREATE TABLE IF NOT EXISTS anomaly_events (
+    id              bigserial PRIMARY KEY,
+    aircraft_id     bigint      NOT NULL,
+    anomaly_type    varchar(32) NOT NULL,
+    status          varchar(16) NOT NULL,
+    severity        varchar(16) NOT NULL,
+    violations      jsonb,
+    first_seen      timestamptz NOT NULL,
+    last_seen       timestamptz NOT NULL,
+    sample_count    bigint      NOT NULL DEFAULT 0,
+    acknowledged_at timestamptz,
+    acknowledged_by text,
+    resolved_at     timestamptz,
+    created_at      timestamptz,
+    updated_at      timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_anomaly_events_aircraft_id ON anomaly_events (aircraft_id);
+CREATE INDEX IF NOT EXISTS idx_anomaly_events_status ON anomaly_events (status);
+
+-- Telemetry from retired aircraft, kept out of telemetry_data
+CREATE TABLE IF NOT EXISTS quarantined_telemetry (
+    id           bigserial PRIMARY KEY,
+    aircraft_id  bigint         NOT NULL,
+    time         timestamptz(6) NOT NULL,
+    latitude     numeric,
+    longitude    numeric,
+    altitude     numeric,
+    ground_speed numeric,
+    heading      numeric,
+    climb_rate   numeric,
+    reason       text           NOT NULL,
+    created_at   timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_quarantined_telemetry_aircraft_time ON quarantined_telemetry (aircraft_id, time);
+
+-- Sightings of MAC addresses without a registered aircraft
+CREATE TABLE IF NOT EXISTS unknown_aircraft_sightings (
+    id             bigserial PRIMARY KEY,
+    mac_address    text        NOT NULL,
+    first_seen     timestamptz NOT NULL,
+    last_seen      timestamptz NOT NULL,
+    message_count  bigint      NOT NULL DEFAULT 0,
+    last_latitude  numeric,
+    last_longitude numeric,
+    last_altitude  numeric,
+    created_at     timestamptz,
+    updated_at     timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unknown_aircraft_sightings_mac_address ON unknown_aircraft_sightings (mac_address);
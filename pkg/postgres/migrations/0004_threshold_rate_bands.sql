@@ -0,0 +1,4 @@
+-- Severity bands for rate-of-change violations, separate from the value bands
+ALTER TABLE IF EXISTS thresholds
+    ADD COLUMN IF NOT EXISTS rate_warning_band  numeric,
+    ADD COLUMN IF NOT EXISTS rate_critical_band numeric;
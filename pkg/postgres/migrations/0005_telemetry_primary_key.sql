@@ -0,0 +1,37 @@
+-- Replaces the legacy time-only primary key of telemetry_data with (aircraft_id, time).
+-- Tables created by 0001 already have it. TimescaleDB can't change constraints while compressed
+-- chunks exist, so those have to be decompressed by an operator first.
+DO $$
+DECLARE
+    current_key text;
+    constraint_name text;
+    compressed_chunks bigint := 0;
+BEGIN
+    SELECT string_agg(a.attname, ',' ORDER BY array_position(i.indkey::int2[], a.attnum))
+    INTO current_key
+    FROM pg_index i
+    JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+    WHERE i.indrelid = 'telemetry_data'::regclass AND i.indisprimary;
+
+    IF current_key = 'aircraft_id,time' THEN
+        RETURN;
+    END IF;
+
+    IF to_regclass('timescaledb_information.chunks') IS NOT NULL THEN
+        EXECUTE 'SELECT count(*) FROM timescaledb_information.chunks WHERE hypertable_name = ''telemetry_data'' AND is_compressed'
+        INTO compressed_chunks;
+    END IF;
+    IF compressed_chunks > 0 THEN
+        RAISE EXCEPTION 'telemetry_data has % compressed chunks; decompress them (decompress_chunk) before migrating its primary key to (aircraft_id, time)', compressed_chunks;
+    END IF;
+
+    SELECT conname INTO constraint_name
+    FROM pg_constraint
+    WHERE conrelid = 'telemetry_data'::regclass AND contype = 'p';
+
+    IF constraint_name IS NOT NULL THEN
+        EXECUTE format('ALTER TABLE telemetry_data DROP CONSTRAINT %I', constraint_name);
+    END IF;
+    ALTER TABLE telemetry_data ADD PRIMARY KEY (aircraft_id, time);
+END
+$$;
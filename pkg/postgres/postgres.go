@@ -59,7 +59,8 @@ func NewClient(config Config) (*gorm.DB, error) {
 	return db, nil
 }
 
-// AutoMigrate runs GORM AutoMigrate for all models
+// AutoMigrate runs GORM AutoMigrate for all models. It is a development convenience;
+// the schema the service depends on is created by Migrate.
 func AutoMigrate(db *gorm.DB) error {
 	logging.Info("Running database migrations")
 
@@ -68,6 +69,10 @@ func AutoMigrate(db *gorm.DB) error {
 		&model.Threshold{},
 		&model.Geofence{},
 		&model.Telemetry{},
+		&model.GeofenceEvent{},
+		&model.QuarantinedTelemetry{},
+		&model.UnknownAircraftSighting{},
+		&model.AnomalyEvent{},
 	); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
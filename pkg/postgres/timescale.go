@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/logging"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const defaultChunkInterval = 24 * time.Hour
+
+// TimescaleConfig holds TimescaleDB hypertable configuration for telemetry data
+type TimescaleConfig struct {
+	ChunkInterval   time.Duration // Time range covered by each chunk
+	SpacePartitions int           // Hash partitions on aircraft_id, 0 disables space partitioning
+	CompressAfter   time.Duration // Age after which chunks are compressed, 0 disables compression
+	RetainFor       time.Duration // Age after which chunks are dropped, 0 disables retention
+}
+
+// setupTimescale converts the telemetry table into a TimescaleDB hypertable and configures
+// compression and retention policies. On PostgreSQL without the timescaledb extension it
+// logs a warning and leaves telemetry as a plain table. It runs inside Migrate's transaction.
+func setupTimescale(db *gorm.DB, config TimescaleConfig) error {
+	if config.ChunkInterval <= 0 {
+		config.ChunkInterval = defaultChunkInterval
+	}
+
+	tableName := model.Telemetry{}.TableName()
+
+	var available bool
+	if err := db.Raw("SELECT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'timescaledb')").Scan(&available).Error; err != nil {
+		return fmt.Errorf("failed to check timescaledb availability: %w", err)
+	}
+	if !available {
+		logging.Warn("TimescaleDB extension is not available, telemetry is stored in a plain PostgreSQL table without compression or retention",
+			zap.String("table", tableName),
+		)
+		return nil
+	}
+
+	if err := execOptional(db, "timescaledb_extension", "CREATE EXTENSION IF NOT EXISTS timescaledb"); err != nil {
+		logging.Warn("Failed to enable TimescaleDB extension, telemetry is stored in a plain PostgreSQL table",
+			zap.Error(err),
+			zap.String("table", tableName),
+		)
+		return nil
+	}
+
+	logging.Info("Setting up TimescaleDB hypertable", zap.String("table", tableName))
+
+	var isHypertable bool
+	if err := db.Raw(
+		"SELECT EXISTS (SELECT 1 FROM timescaledb_information.hypertables WHERE hypertable_name = ?)",
+		tableName,
+	).Scan(&isHypertable).Error; err != nil {
+		return fmt.Errorf("failed to check hypertable: %w", err)
+	}
+
+	if !isHypertable {
+		// Space partitioning is set up with the hypertable itself; add_dimension only works while it is empty
+		query := "SELECT create_hypertable(?, 'time', chunk_time_interval => ?::interval, if_not_exists => TRUE, migrate_data => TRUE)"
+		args := []interface{}{tableName, intervalString(config.ChunkInterval)}
+		if config.SpacePartitions > 0 {
+			query = "SELECT create_hypertable(?, 'time', partitioning_column => 'aircraft_id', number_partitions => ?, chunk_time_interval => ?::interval, if_not_exists => TRUE, migrate_data => TRUE)"
+			args = []interface{}{tableName, config.SpacePartitions, intervalString(config.ChunkInterval)}
+		}
+		if err := db.Exec(query, args...).Error; err != nil {
+			return fmt.Errorf("failed to create hypertable: %w", err)
+		}
+	} else if config.SpacePartitions > 0 {
+		// Existing hypertable; TimescaleDB only allows adding a dimension while it has no data
+		if err := execOptional(db, "timescaledb_dimension",
+			"SELECT add_dimension(?, 'aircraft_id', number_partitions => ?, if_not_exists => TRUE)",
+			tableName, config.SpacePartitions,
+		); err != nil {
+			logging.Warn("Failed to partition existing hypertable by aircraft_id, continuing with time partitioning only",
+				zap.Error(err),
+				zap.String("table", tableName),
+			)
+		}
+	}
+
+	if config.CompressAfter > 0 {
+		if err := setupCompression(db, tableName, config.CompressAfter); err != nil {
+			return err
+		}
+	}
+
+	if err := db.Exec("SELECT remove_retention_policy(?, if_exists => TRUE)", tableName).Error; err != nil {
+		return fmt.Errorf("failed to remove retention policy: %w", err)
+	}
+	if config.RetainFor > 0 {
+		if err := db.Exec(
+			"SELECT add_retention_policy(?, ?::interval)",
+			tableName, intervalString(config.RetainFor),
+		).Error; err != nil {
+			return fmt.Errorf("failed to add retention policy: %w", err)
+		}
+	}
+
+	logging.Info("TimescaleDB hypertable configured",
+		zap.String("table", tableName),
+		zap.Duration("chunk_interval", config.ChunkInterval),
+		zap.Int("space_partitions", config.SpacePartitions),
+		zap.Duration("compress_after", config.CompressAfter),
+		zap.Duration("retain_for", config.RetainFor),
+	)
+
+	return nil
+}
+
+// setupCompression enables native compression segmented by aircraft and (re)creates the compression policy
+func setupCompression(db *gorm.DB, tableName string, compressAfter time.Duration) error {
+	var compressionEnabled bool
+	if err := db.Raw(
+		"SELECT compression_enabled FROM timescaledb_information.hypertables WHERE hypertable_name = ?",
+		tableName,
+	).Scan(&compressionEnabled).Error; err != nil {
+		return fmt.Errorf("failed to check hypertable compression: %w", err)
+	}
+
+	// Compression settings cannot be changed while compressed chunks exist, so only set them once
+	if !compressionEnabled {
+		if err := db.Exec(fmt.Sprintf(
+			"ALTER TABLE %s SET (timescaledb.compress, timescaledb.compress_segmentby = 'aircraft_id', timescaledb.compress_orderby = 'time DESC')",
+			tableName,
+		)).Error; err != nil {
+			return fmt.Errorf("failed to enable hypertable compression: %w", err)
+		}
+	}
+
+	if err := db.Exec("SELECT remove_compression_policy(?, if_exists => TRUE)", tableName).Error; err != nil {
+		return fmt.Errorf("failed to remove compression policy: %w", err)
+	}
+	if err := db.Exec(
+		"SELECT add_compression_policy(?, ?::interval)",
+		tableName, intervalString(compressAfter),
+	).Error; err != nil {
+		return fmt.Errorf("failed to add compression policy: %w", err)
+	}
+
+	return nil
+}
+
+// intervalString formats a duration as a PostgreSQL interval literal
+func intervalString(d time.Duration) string {
+	return fmt.Sprintf("%d seconds", int64(d.Seconds()))
+}
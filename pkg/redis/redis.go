@@ -22,8 +22,20 @@ type Client interface {
 	ReadFromStream(ctx context.Context, streamKey, groupName, consumerName string, count int64) ([]redis.XStream, error)
 	// AcknowledgeStream acknowledges processed messages
 	AcknowledgeStream(ctx context.Context, streamKey, groupName string, ids ...string) error
+	// PendingFromStream lists pending entries of a consumer group from start on that have been idle for at least minIdle
+	PendingFromStream(ctx context.Context, streamKey, groupName, start string, minIdle time.Duration, count int64) ([]redis.XPendingExt, error)
+	// ClaimFromStream transfers ownership of pending entries to the given consumer
+	ClaimFromStream(ctx context.Context, streamKey, groupName, consumerName string, minIdle time.Duration, ids ...string) ([]redis.XMessage, error)
+	// RangeStream reads entries of a stream between start and end IDs without a consumer group
+	RangeStream(ctx context.Context, streamKey, start, end string, count int64) ([]redis.XMessage, error)
+	// DeleteFromStream removes entries from a stream
+	DeleteFromStream(ctx context.Context, streamKey string, ids ...string) error
+	// MoveToStream writes data to a stream and deletes an entry from another one in a single transaction
+	MoveToStream(ctx context.Context, fromStreamKey, id, toStreamKey string, data map[string]interface{}) error
 	// PublishToChannel publishes message to Redis Pub/Sub channel
 	PublishToChannel(ctx context.Context, channel string, message interface{}) error
+	// SubscribeToChannel subscribes to Redis Pub/Sub channels
+	SubscribeToChannel(ctx context.Context, channels ...string) *redis.PubSub
 	// WriteToDiskBuffer writes data to disk buffer as fallback
 	WriteToDiskBuffer(ctx context.Context, payload []byte) error
 	// RecoverFromDisk recovers data from disk buffer to Redis
@@ -109,6 +121,33 @@ func NewClientWithDiskBuffer(ctx context.Context, config Config, diskConfig Disk
 
 // WriteToStream writes data to Redis stream
 func (c *redisClient) WriteToStream(ctx context.Context, streamKey string, data map[string]interface{}) error {
+	_, err := c.rdb.XAdd(ctx, streamAddArgs(streamKey, data)).Result()
+
+	if err != nil {
+		return fmt.Errorf("redis stream write error: %w", err)
+	}
+
+	return nil
+}
+
+// MoveToStream writes data to toStreamKey and deletes id from fromStreamKey in a MULTI/EXEC
+// transaction, so the entry is never left in both streams
+func (c *redisClient) MoveToStream(ctx context.Context, fromStreamKey, id, toStreamKey string, data map[string]interface{}) error {
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, streamAddArgs(toStreamKey, data))
+		pipe.XDel(ctx, fromStreamKey, id)
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("redis stream move error: %w", err)
+	}
+
+	return nil
+}
+
+// streamAddArgs builds XADD arguments for data
+func streamAddArgs(streamKey string, data map[string]interface{}) *redis.XAddArgs {
 	// Convert time.Time values to RFC3339 strings for Redis compatibility
 	convertedData := make(map[string]interface{})
 	for k, v := range data {
@@ -120,17 +159,11 @@ func (c *redisClient) WriteToStream(ctx context.Context, streamKey string, data
 		}
 	}
 
-	_, err := c.rdb.XAdd(ctx, &redis.XAddArgs{
+	return &redis.XAddArgs{
 		Stream: streamKey,
 		MaxLen: 100000, // Optional: limit stream length
 		Values: convertedData,
-	}).Result()
-
-	if err != nil {
-		return fmt.Errorf("redis stream write error: %w", err)
 	}
-
-	return nil
 }
 
 // WriteToDiskBuffer writes data to disk buffer as fallback
@@ -197,6 +230,70 @@ func (c *redisClient) AcknowledgeStream(ctx context.Context, streamKey, groupNam
 	return nil
 }
 
+// PendingFromStream lists pending entries of a consumer group from start on that have been idle for at least minIdle.
+// start is an entry ID, "-" for the beginning, or "(" followed by an ID to start after it.
+func (c *redisClient) PendingFromStream(ctx context.Context, streamKey, groupName, start string, minIdle time.Duration, count int64) ([]redis.XPendingExt, error) {
+	pending, err := c.rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: streamKey,
+		Group:  groupName,
+		Idle:   minIdle,
+		Start:  start,
+		End:    "+",
+		Count:  count,
+	}).Result()
+
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("redis stream pending error: %w", err)
+	}
+
+	return pending, nil
+}
+
+// ClaimFromStream transfers ownership of pending entries to the given consumer
+func (c *redisClient) ClaimFromStream(ctx context.Context, streamKey, groupName, consumerName string, minIdle time.Duration, ids ...string) ([]redis.XMessage, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	messages, err := c.rdb.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   streamKey,
+		Group:    groupName,
+		Consumer: consumerName,
+		MinIdle:  minIdle,
+		Messages: ids,
+	}).Result()
+
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("redis stream claim error: %w", err)
+	}
+
+	return messages, nil
+}
+
+// RangeStream reads entries of a stream between start and end IDs without a consumer group
+func (c *redisClient) RangeStream(ctx context.Context, streamKey, start, end string, count int64) ([]redis.XMessage, error) {
+	messages, err := c.rdb.XRangeN(ctx, streamKey, start, end, count).Result()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("redis stream range error: %w", err)
+	}
+
+	return messages, nil
+}
+
+// DeleteFromStream removes entries from a stream
+func (c *redisClient) DeleteFromStream(ctx context.Context, streamKey string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := c.rdb.XDel(ctx, streamKey, ids...).Result()
+	if err != nil {
+		return fmt.Errorf("redis stream delete error: %w", err)
+	}
+
+	return nil
+}
+
 // PublishToChannel publishes message to Redis Pub/Sub channel
 func (c *redisClient) PublishToChannel(ctx context.Context, channel string, message interface{}) error {
 	var msg string
@@ -222,6 +319,12 @@ func (c *redisClient) PublishToChannel(ctx context.Context, channel string, mess
 	return nil
 }
 
+// SubscribeToChannel subscribes to Redis Pub/Sub channels.
+// The caller must close the returned subscription.
+func (c *redisClient) SubscribeToChannel(ctx context.Context, channels ...string) *redis.PubSub {
+	return c.rdb.Subscribe(ctx, channels...)
+}
+
 // GetRawClient returns the underlying redis client for advanced operations
 func (c *redisClient) GetRawClient() *redis.Client {
 	return c.rdb
@@ -15,20 +15,25 @@ import (
 type FeedPublisher interface {
 	PublishGlobalTelemetry(ctx context.Context, telemetry *model.Telemetry) error
 	PublishAlert(ctx context.Context, telemetry *model.Telemetry, anomaly *model.Anomaly) error
+	PublishGeofenceEvent(ctx context.Context, event *model.GeofenceEvent) error
+	PublishUnknownAircraft(ctx context.Context, sighting *model.UnknownAircraftSighting) error
+	PublishAnomalyEvent(ctx context.Context, event *model.AnomalyEvent) error
 }
 
 type feedPublisher struct {
-	redisClient       redis.Client
-	globalFeedChannel string
-	alertFeedChannel  string
+	redisClient            redis.Client
+	globalFeedChannel      string
+	alertFeedChannel       string
+	unknownAircraftChannel string
 }
 
 // NewFeedPublisher creates a new feed publisher
-func NewFeedPublisher(redisClient redis.Client, globalFeedChannel, alertFeedChannel string) FeedPublisher {
+func NewFeedPublisher(redisClient redis.Client, globalFeedChannel, alertFeedChannel, unknownAircraftChannel string) FeedPublisher {
 	return &feedPublisher{
-		redisClient:       redisClient,
-		globalFeedChannel: globalFeedChannel,
-		alertFeedChannel:  alertFeedChannel,
+		redisClient:            redisClient,
+		globalFeedChannel:      globalFeedChannel,
+		alertFeedChannel:       alertFeedChannel,
+		unknownAircraftChannel: unknownAircraftChannel,
 	}
 }
 
@@ -69,3 +74,66 @@ func (p *feedPublisher) PublishAlert(ctx context.Context, telemetry *model.Telem
 
 	return nil
 }
+
+// PublishGeofenceEvent publishes a geofence entry, exit or dwell event to alert_feed
+func (p *feedPublisher) PublishGeofenceEvent(ctx context.Context, event *model.GeofenceEvent) error {
+	alertData := map[string]interface{}{
+		"geofence_event": event,
+	}
+
+	data, err := json.Marshal(alertData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal geofence event message: %w", err)
+	}
+
+	if err := p.redisClient.PublishToChannel(ctx, p.alertFeedChannel, data); err != nil {
+		return fmt.Errorf("failed to publish geofence event to alert feed: %w", err)
+	}
+
+	logging.Info("Geofence event published to alert feed",
+		zap.Uint("aircraft_id", event.AircraftID),
+		zap.Uint("geofence_id", event.GeofenceID),
+		zap.String("event_type", string(event.EventType)),
+	)
+
+	return nil
+}
+
+// PublishAnomalyEvent publishes an anomaly incident state transition to alert_feed
+func (p *feedPublisher) PublishAnomalyEvent(ctx context.Context, event *model.AnomalyEvent) error {
+	alertData := map[string]interface{}{
+		"anomaly_event": event,
+	}
+
+	data, err := json.Marshal(alertData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal anomaly event message: %w", err)
+	}
+
+	if err := p.redisClient.PublishToChannel(ctx, p.alertFeedChannel, data); err != nil {
+		return fmt.Errorf("failed to publish anomaly event to alert feed: %w", err)
+	}
+
+	logging.Info("Anomaly event published to alert feed",
+		zap.Uint("anomaly_event_id", event.ID),
+		zap.Uint("aircraft_id", event.AircraftID),
+		zap.String("anomaly_type", string(event.AnomalyType)),
+		zap.String("status", string(event.Status)),
+	)
+
+	return nil
+}
+
+// PublishUnknownAircraft publishes a sighting of an unregistered aircraft to the unknown aircraft channel
+func (p *feedPublisher) PublishUnknownAircraft(ctx context.Context, sighting *model.UnknownAircraftSighting) error {
+	data, err := json.Marshal(sighting)
+	if err != nil {
+		return fmt.Errorf("failed to marshal unknown aircraft message: %w", err)
+	}
+
+	if err := p.redisClient.PublishToChannel(ctx, p.unknownAircraftChannel, data); err != nil {
+		return fmt.Errorf("failed to publish to unknown aircraft channel: %w", err)
+	}
+
+	return nil
+}
@@ -3,12 +3,14 @@ package repository
 import (
 	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // AircraftRepository defines aircraft repository operations
 type AircraftRepository interface {
 	GetByMACAddress(macAddress string) (*model.Aircraft, error)
 	GetByID(id uint) (*model.Aircraft, error)
+	CreateIfNotExists(aircraft *model.Aircraft) (*model.Aircraft, error)
 }
 
 type aircraftRepository struct {
@@ -43,3 +45,25 @@ func (r *aircraftRepository) GetByID(id uint) (*model.Aircraft, error) {
 	}
 	return &aircraft, nil
 }
+
+// CreateIfNotExists creates an aircraft unless one with the same MAC address exists,
+// and returns the stored aircraft either way. A soft-deleted aircraft with the MAC address
+// still holds the unique index; it is returned as is, with DeletedAt set, and not restored.
+func (r *aircraftRepository) CreateIfNotExists(aircraft *model.Aircraft) (*model.Aircraft, error) {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "mac_address"}},
+		DoNothing: true,
+	}).Create(aircraft).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var stored model.Aircraft
+	if err := r.db.Unscoped().Where("mac_address = ?", aircraft.MACAddress).First(&stored).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &stored, nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AnomalyEventRepository defines anomaly event repository operations
+type AnomalyEventRepository interface {
+	Create(event *model.AnomalyEvent) error
+	UpdateActivity(event *model.AnomalyEvent) error
+	Acknowledge(id uint, acknowledgedBy string, at time.Time) (*model.AnomalyEvent, error)
+	Resolve(id uint, at time.Time) error
+	GetUnresolved() ([]*model.AnomalyEvent, error)
+	List(status model.AnomalyEventStatus, limit int) ([]*model.AnomalyEvent, error)
+}
+
+type anomalyEventRepository struct {
+	db *gorm.DB
+}
+
+// NewAnomalyEventRepository creates a new anomaly event repository
+func NewAnomalyEventRepository(db *gorm.DB) AnomalyEventRepository {
+	return &anomalyEventRepository{db: db}
+}
+
+// Create creates a new anomaly event record
+func (r *anomalyEventRepository) Create(event *model.AnomalyEvent) error {
+	return r.db.Create(event).Error
+}
+
+// UpdateActivity stores the latest sample of an ongoing incident without touching its status
+func (r *anomalyEventRepository) UpdateActivity(event *model.AnomalyEvent) error {
+	return r.db.Model(event).Select("severity", "violations", "last_seen", "sample_count").Updates(event).Error
+}
+
+// Acknowledge marks an open anomaly event as acknowledged and returns it.
+// Returns nil if the event does not exist or is not open.
+func (r *anomalyEventRepository) Acknowledge(id uint, acknowledgedBy string, at time.Time) (*model.AnomalyEvent, error) {
+	var events []*model.AnomalyEvent
+	result := r.db.Model(&events).
+		Clauses(clause.Returning{}).
+		Where("id = ? AND status = ?", id, model.AnomalyEventOpen).
+		Updates(map[string]interface{}{
+			"status":          model.AnomalyEventAcknowledged,
+			"acknowledged_at": at,
+			"acknowledged_by": acknowledgedBy,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return events[0], nil
+}
+
+// Resolve marks an anomaly event as resolved
+func (r *anomalyEventRepository) Resolve(id uint, at time.Time) error {
+	return r.db.Model(&model.AnomalyEvent{}).
+		Where("id = ? AND status <> ?", id, model.AnomalyEventResolved).
+		Updates(map[string]interface{}{
+			"status":      model.AnomalyEventResolved,
+			"resolved_at": at,
+		}).Error
+}
+
+// GetUnresolved retrieves all open and acknowledged anomaly events
+func (r *anomalyEventRepository) GetUnresolved() ([]*model.AnomalyEvent, error) {
+	var events []*model.AnomalyEvent
+	if err := r.db.Where("status <> ?", model.AnomalyEventResolved).Order("id").Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// List retrieves anomaly events, most severe and then most recent first, optionally filtered by status
+func (r *anomalyEventRepository) List(status model.AnomalyEventStatus, limit int) ([]*model.AnomalyEvent, error) {
+	var events []*model.AnomalyEvent
+	query := r.db.Order(clause.Expr{
+		SQL:  "CASE severity WHEN ? THEN 2 WHEN ? THEN 1 ELSE 0 END DESC, last_seen DESC, id DESC",
+		Vars: []interface{}{model.SeverityCritical, model.SeverityWarning},
+	}).Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"gorm.io/gorm"
+)
+
+// GeofenceEventRepository defines geofence event repository operations
+type GeofenceEventRepository interface {
+	CreateBatch(events []*model.GeofenceEvent) error
+	GetOpen() ([]*model.GeofenceEvent, error)
+}
+
+type geofenceEventRepository struct {
+	db *gorm.DB
+}
+
+// NewGeofenceEventRepository creates a new geofence event repository
+func NewGeofenceEventRepository(db *gorm.DB) GeofenceEventRepository {
+	return &geofenceEventRepository{db: db}
+}
+
+// CreateBatch creates multiple geofence event records
+func (r *geofenceEventRepository) CreateBatch(events []*model.GeofenceEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.db.Create(events).Error
+}
+
+// GetOpen retrieves the latest event of every (aircraft, geofence) incursion that has not exited yet
+func (r *geofenceEventRepository) GetOpen() ([]*model.GeofenceEvent, error) {
+	var events []*model.GeofenceEvent
+	query := fmt.Sprintf(`
+		SELECT * FROM (
+			SELECT DISTINCT ON (aircraft_id, geofence_id) *
+			FROM %s
+			ORDER BY aircraft_id, geofence_id, occurred_at DESC, id DESC
+		) latest
+		WHERE event_type <> ?`, model.GeofenceEvent{}.TableName())
+	if err := r.db.Raw(query, model.GeofenceEventExited).Scan(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
 	"gorm.io/gorm"
 )
@@ -8,6 +10,7 @@ import (
 // GeofenceRepository defines geofence repository operations
 type GeofenceRepository interface {
 	GetAllActive() ([]*model.Geofence, error)
+	GetLastChange() (time.Time, int64, error)
 }
 
 type geofenceRepository struct {
@@ -27,3 +30,22 @@ func (r *geofenceRepository) GetAllActive() ([]*model.Geofence, error) {
 	}
 	return geofences, nil
 }
+
+// GetLastChange returns the latest modification time across all geofences, including
+// soft-deleted ones, and the total row count. Together they change whenever a geofence is
+// created, updated or deleted.
+func (r *geofenceRepository) GetLastChange() (time.Time, int64, error) {
+	var result struct {
+		LastChange *time.Time
+		Total      int64
+	}
+	if err := r.db.Unscoped().Model(&model.Geofence{}).
+		Select("MAX(GREATEST(updated_at, COALESCE(deleted_at, updated_at))) AS last_change, COUNT(*) AS total").
+		Scan(&result).Error; err != nil {
+		return time.Time{}, 0, err
+	}
+	if result.LastChange == nil {
+		return time.Time{}, result.Total, nil
+	}
+	return *result.LastChange, result.Total, nil
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QuarantineRepository defines quarantined telemetry repository operations
+type QuarantineRepository interface {
+	Create(telemetry *model.QuarantinedTelemetry) error
+}
+
+type quarantineRepository struct {
+	db *gorm.DB
+}
+
+// NewQuarantineRepository creates a new quarantine repository
+func NewQuarantineRepository(db *gorm.DB) QuarantineRepository {
+	return &quarantineRepository{db: db}
+}
+
+// Create creates a quarantined telemetry record.
+// Redelivered entries for the same (aircraft_id, time) are ignored.
+func (r *quarantineRepository) Create(telemetry *model.QuarantinedTelemetry) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(telemetry).Error
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"gorm.io/gorm"
+)
+
+// telemetryStagingTable is the temporary table rows are copied into before being merged into
+// telemetry_data, since COPY itself cannot skip conflicting rows. It is created in every
+// transaction and dropped on commit, so it always matches the current telemetry_data columns.
+const telemetryStagingTable = "telemetry_data_staging"
+
+// telemetryCopyColumns lists the telemetry_data columns written by the COPY protocol
+var telemetryCopyColumns = []string{
+	"time",
+	"aircraft_id",
+	"latitude",
+	"longitude",
+	"altitude",
+	"ground_speed",
+	"heading",
+	"climb_rate",
+	"temperature",
+	"has_anomaly",
+	"anomaly_type",
+	"violations",
+	"created_at",
+}
+
+type telemetryCopyRepository struct {
+	db *gorm.DB
+}
+
+// NewTelemetryCopyRepository creates a telemetry repository that writes batches with the
+// PostgreSQL COPY protocol instead of multi-row INSERT statements
+func NewTelemetryCopyRepository(db *gorm.DB) TelemetryRepository {
+	return &telemetryCopyRepository{db: db}
+}
+
+// Create creates a new telemetry record
+func (r *telemetryCopyRepository) Create(ctx context.Context, telemetry *model.Telemetry) error {
+	return r.CreateBatch(ctx, []*model.Telemetry{telemetry})
+}
+
+// CreateBatch copies multiple telemetry records into telemetry_data, ignoring rows that already exist
+func (r *telemetryCopyRepository) CreateBatch(ctx context.Context, telemetries []*model.Telemetry) error {
+	if len(telemetries) == 0 {
+		return nil
+	}
+
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %w", err)
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire database connection: %w", err)
+	}
+	defer conn.Close()
+
+	now := time.Now()
+	rows := make([][]interface{}, len(telemetries))
+	for i, t := range telemetries {
+		// COPY bypasses GORM hooks, so apply the same defaults as Telemetry.BeforeCreate
+		if t.Time.IsZero() {
+			t.Time = now
+		}
+		if t.CreatedAt.IsZero() {
+			t.CreatedAt = now
+		}
+		var violations []byte
+		if len(t.Violations) > 0 {
+			if violations, err = json.Marshal(t.Violations); err != nil {
+				return fmt.Errorf("failed to marshal telemetry violations: %w", err)
+			}
+		}
+		rows[i] = []interface{}{
+			t.Time,
+			t.AircraftID,
+			t.Latitude,
+			t.Longitude,
+			t.Altitude,
+			t.GroundSpeed,
+			t.Heading,
+			t.ClimbRate,
+			t.Temperature,
+			t.HasAnomaly,
+			t.AnomalyType,
+			violations,
+			t.CreatedAt,
+		}
+	}
+
+	return conn.Raw(func(driverConn interface{}) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected database driver connection: %T", driverConn)
+		}
+
+		pgxConn := stdlibConn.Conn()
+		tx, err := pgxConn.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to begin telemetry copy transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		tableName := pgx.Identifier{model.Telemetry{}.TableName()}.Sanitize()
+		stagingName := pgx.Identifier{telemetryStagingTable}.Sanitize()
+		columns := strings.Join(telemetryCopyColumns, ", ")
+
+		if _, err := tx.Exec(ctx, fmt.Sprintf(
+			"CREATE TEMP TABLE %s (LIKE %s INCLUDING DEFAULTS) ON COMMIT DROP",
+			stagingName, tableName,
+		)); err != nil {
+			return fmt.Errorf("failed to create telemetry staging table: %w", err)
+		}
+
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{telemetryStagingTable}, telemetryCopyColumns, pgx.CopyFromRows(rows)); err != nil {
+			return fmt.Errorf("failed to copy telemetry rows: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, fmt.Sprintf(
+			"INSERT INTO %s (%s) SELECT %s FROM %s ON CONFLICT (aircraft_id, time) DO NOTHING",
+			tableName, columns, columns, stagingName,
+		)); err != nil {
+			return fmt.Errorf("failed to merge telemetry rows: %w", err)
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("failed to commit telemetry copy transaction: %w", err)
+		}
+
+		return nil
+	})
+}
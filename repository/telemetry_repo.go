@@ -1,14 +1,17 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // TelemetryRepository defines telemetry repository operations
 type TelemetryRepository interface {
-	Create(telemetry *model.Telemetry) error
-	CreateBatch(telemetries []*model.Telemetry) error
+	Create(ctx context.Context, telemetry *model.Telemetry) error
+	CreateBatch(ctx context.Context, telemetries []*model.Telemetry) error
 }
 
 type telemetryRepository struct {
@@ -20,15 +23,15 @@ func NewTelemetryRepository(db *gorm.DB) TelemetryRepository {
 	return &telemetryRepository{db: db}
 }
 
-// Create creates a new telemetry record
-func (r *telemetryRepository) Create(telemetry *model.Telemetry) error {
-	return r.db.Create(telemetry).Error
+// Create creates a new telemetry record, ignoring it if a row for the same aircraft and time exists
+func (r *telemetryRepository) Create(ctx context.Context, telemetry *model.Telemetry) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(telemetry).Error
 }
 
-// CreateBatch creates multiple telemetry records in a batch
-func (r *telemetryRepository) CreateBatch(telemetries []*model.Telemetry) error {
+// CreateBatch creates multiple telemetry records in a batch, ignoring rows that already exist
+func (r *telemetryRepository) CreateBatch(ctx context.Context, telemetries []*model.Telemetry) error {
 	if len(telemetries) == 0 {
 		return nil
 	}
-	return r.db.CreateInBatches(telemetries, 100).Error
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(telemetries, 100).Error
 }
@@ -0,0 +1,93 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/repository"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Benchmarks run against a real database, e.g.
+// BENCH_POSTGRES_DSN="host=localhost user=postgres password=postgres dbname=bench sslmode=disable" go test -bench Telemetry ./repository
+const benchDSNEnv = "BENCH_POSTGRES_DSN"
+
+func openBenchDB(b *testing.B) *gorm.DB {
+	b.Helper()
+
+	dsn := os.Getenv(benchDSNEnv)
+	if dsn == "" {
+		b.Skipf("%s not set", benchDSNEnv)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		b.Fatalf("failed to connect to database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&model.Telemetry{}); err != nil {
+		b.Fatalf("failed to migrate telemetry table: %v", err)
+	}
+
+	return db
+}
+
+func benchTelemetryBatch(offset, size int) []*model.Telemetry {
+	base := time.Now()
+	telemetries := make([]*model.Telemetry, size)
+	for i := range telemetries {
+		telemetries[i] = &model.Telemetry{
+			// Spread rows over time so primary keys don't collide between iterations
+			Time:        base.Add(time.Duration(offset*size+i) * time.Microsecond),
+			AircraftID:  uint(i%50 + 1),
+			Latitude:    41.0 + float64(i%100)*0.001,
+			Longitude:   29.0 + float64(i%100)*0.001,
+			Altitude:    10000,
+			GroundSpeed: 450,
+			Heading:     float64(i % 360),
+			ClimbRate:   0,
+		}
+	}
+	return telemetries
+}
+
+func benchmarkCreateBatch(b *testing.B, newRepo func(db *gorm.DB) repository.TelemetryRepository) {
+	db := openBenchDB(b)
+	repo := newRepo(db)
+
+	for _, size := range []int{100, 1000, 5000} {
+		b.Run(fmt.Sprintf("rows=%d", size), func(b *testing.B) {
+			batches := make([][]*model.Telemetry, b.N)
+			for i := range batches {
+				batches[i] = benchTelemetryBatch(i, size)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := repo.CreateBatch(context.Background(), batches[i]); err != nil {
+					b.Fatalf("CreateBatch failed: %v", err)
+				}
+			}
+			b.StopTimer()
+
+			b.ReportMetric(float64(b.N*size)/b.Elapsed().Seconds(), "rows/s")
+			db.Exec("TRUNCATE telemetry_data")
+		})
+	}
+}
+
+func BenchmarkTelemetryRepository_GORM_CreateBatch(b *testing.B) {
+	benchmarkCreateBatch(b, repository.NewTelemetryRepository)
+}
+
+func BenchmarkTelemetryRepository_Copy_CreateBatch(b *testing.B) {
+	benchmarkCreateBatch(b, repository.NewTelemetryCopyRepository)
+}
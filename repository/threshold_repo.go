@@ -10,6 +10,7 @@ type ThresholdRepository interface {
 	GetByAircraftID(aircraftID uint) ([]*model.Threshold, error)
 	GetDefaults() ([]*model.Threshold, error)
 	GetByAircraftIDAndMetric(aircraftID uint, metricName string) (*model.Threshold, error)
+	GetAll() ([]*model.Threshold, error)
 }
 
 type thresholdRepository struct {
@@ -43,7 +44,7 @@ func (r *thresholdRepository) GetDefaults() ([]*model.Threshold, error) {
 // Falls back to default if aircraft-specific threshold doesn't exist
 func (r *thresholdRepository) GetByAircraftIDAndMetric(aircraftID uint, metricName string) (*model.Threshold, error) {
 	var threshold model.Threshold
-	
+
 	// First try to get aircraft-specific threshold
 	err := r.db.Where("aircraft_id = ? AND metric_name = ?", aircraftID, metricName).First(&threshold).Error
 	if err == nil {
@@ -64,3 +65,11 @@ func (r *thresholdRepository) GetByAircraftIDAndMetric(aircraftID uint, metricNa
 	return &threshold, nil
 }
 
+// GetAll retrieves all aircraft-specific and default thresholds
+func (r *thresholdRepository) GetAll() ([]*model.Threshold, error) {
+	var thresholds []*model.Threshold
+	if err := r.db.Find(&thresholds).Error; err != nil {
+		return nil, err
+	}
+	return thresholds, nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UnknownAircraftRepository defines unknown aircraft sighting repository operations
+type UnknownAircraftRepository interface {
+	RecordSighting(sighting *model.UnknownAircraftSighting) error
+}
+
+type unknownAircraftRepository struct {
+	db *gorm.DB
+}
+
+// NewUnknownAircraftRepository creates a new unknown aircraft sighting repository
+func NewUnknownAircraftRepository(db *gorm.DB) UnknownAircraftRepository {
+	return &unknownAircraftRepository{db: db}
+}
+
+// RecordSighting inserts a sighting or merges it into the existing row for the MAC address.
+// The last position only moves forward in time, so out-of-order messages don't overwrite it.
+// The message count is approximate: stream entries that are redelivered are counted again.
+// On return sighting holds the merged row.
+func (r *unknownAircraftRepository) RecordSighting(sighting *model.UnknownAircraftSighting) error {
+	sighting.MessageCount = 1
+	newer := "excluded.last_seen >= unknown_aircraft_sightings.last_seen"
+	return r.db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "mac_address"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"first_seen":     gorm.Expr("LEAST(unknown_aircraft_sightings.first_seen, excluded.first_seen)"),
+				"last_seen":      gorm.Expr("GREATEST(unknown_aircraft_sightings.last_seen, excluded.last_seen)"),
+				"message_count":  gorm.Expr("unknown_aircraft_sightings.message_count + 1"),
+				"last_latitude":  gorm.Expr("CASE WHEN " + newer + " THEN excluded.last_latitude ELSE unknown_aircraft_sightings.last_latitude END"),
+				"last_longitude": gorm.Expr("CASE WHEN " + newer + " THEN excluded.last_longitude ELSE unknown_aircraft_sightings.last_longitude END"),
+				"last_altitude":  gorm.Expr("CASE WHEN " + newer + " THEN excluded.last_altitude ELSE unknown_aircraft_sightings.last_altitude END"),
+				"updated_at":     gorm.Expr("excluded.updated_at"),
+			}),
+		},
+		clause.Returning{},
+	).Create(sighting).Error
+}
@@ -1,25 +1,93 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/logging"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/redis"
 	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultAircraftCacheTTL         = 5 * time.Minute
+	defaultAircraftCacheNegativeTTL = time.Minute
+)
+
+var (
+	// ErrAircraftNotFound is returned when no aircraft is registered for a MAC address
+	ErrAircraftNotFound = errors.New("aircraft not found")
+	// ErrAircraftDeleted is returned when registering a MAC address whose aircraft was deleted by an operator
+	ErrAircraftDeleted = errors.New("aircraft deleted")
 )
 
 // AircraftService handles aircraft-related operations
 type AircraftService interface {
 	GetAircraftByMAC(macAddress string) (*model.Aircraft, error)
+	RegisterAircraft(macAddress string, ownerID uint) (*model.Aircraft, error)
+	Invalidate(macAddress string)
+	InvalidateAll()
+	CacheStats() AircraftCacheStats
+	WatchChanges(ctx context.Context)
+}
+
+// AircraftCacheStats reports aircraft cache usage
+type AircraftCacheStats struct {
+	Entries      int     `json:"entries"`
+	Hits         int64   `json:"hits"`
+	NegativeHits int64   `json:"negative_hits"` // Hits on cached unknown MACs
+	Misses       int64   `json:"misses"`
+	HitRate      float64 `json:"hit_rate"` // (hits + negative hits) / lookups
+}
+
+// aircraftCacheEntry is a cached lookup result; a nil aircraft marks an unknown MAC
+type aircraftCacheEntry struct {
+	aircraft  *model.Aircraft
+	expiresAt time.Time
 }
 
 type aircraftService struct {
-	aircraftRepo repository.AircraftRepository
+	aircraftRepo   repository.AircraftRepository
+	redisClient    redis.Client
+	controlChannel string
+	ttl            time.Duration
+	negativeTTL    time.Duration
+	mu             sync.Mutex
+	cache          map[string]aircraftCacheEntry
+	hits           int64
+	negativeHits   int64
+	misses         int64
 }
 
-// NewAircraftService creates a new aircraft service
-func NewAircraftService(aircraftRepo repository.AircraftRepository) AircraftService {
+// NewAircraftService creates a new aircraft service.
+// Lookups are cached for ttl, unknown MACs for negativeTTL. Call WatchChanges to drop entries when a
+// control message arrives on controlChannel.
+func NewAircraftService(
+	aircraftRepo repository.AircraftRepository,
+	redisClient redis.Client,
+	controlChannel string,
+	ttl time.Duration,
+	negativeTTL time.Duration,
+) AircraftService {
+	if ttl <= 0 {
+		ttl = defaultAircraftCacheTTL
+	}
+	if negativeTTL <= 0 {
+		negativeTTL = defaultAircraftCacheNegativeTTL
+	}
+
 	return &aircraftService{
-		aircraftRepo: aircraftRepo,
+		aircraftRepo:   aircraftRepo,
+		redisClient:    redisClient,
+		controlChannel: controlChannel,
+		ttl:            ttl,
+		negativeTTL:    negativeTTL,
+		cache:          make(map[string]aircraftCacheEntry),
 	}
 }
 
@@ -29,14 +97,157 @@ func (s *aircraftService) GetAircraftByMAC(macAddress string) (*model.Aircraft,
 		return nil, fmt.Errorf("mac address cannot be empty")
 	}
 
-	aircraft, err := s.aircraftRepo.GetByMACAddress(macAddress)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get aircraft by MAC: %w", err)
+	aircraft, cached := s.lookup(macAddress)
+	if !cached {
+		var err error
+		aircraft, err = s.aircraftRepo.GetByMACAddress(macAddress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get aircraft by MAC: %w", err)
+		}
+		s.store(macAddress, aircraft)
 	}
 
 	if aircraft == nil {
-		return nil, fmt.Errorf("aircraft not found for MAC address: %s", macAddress)
+		return nil, fmt.Errorf("%w for MAC address: %s", ErrAircraftNotFound, macAddress)
 	}
 
 	return aircraft, nil
 }
+
+// RegisterAircraft registers an active aircraft for a MAC address under the given owner.
+// If the MAC address is already registered the existing aircraft is returned. A deleted aircraft
+// is not brought back; restoring it is left to an operator and ErrAircraftDeleted is returned.
+func (s *aircraftService) RegisterAircraft(macAddress string, ownerID uint) (*model.Aircraft, error) {
+	if macAddress == "" {
+		return nil, fmt.Errorf("mac address cannot be empty")
+	}
+
+	aircraft, err := s.aircraftRepo.CreateIfNotExists(&model.Aircraft{
+		MACAddress: macAddress,
+		Name:       macAddress,
+		OwnerID:    ownerID,
+		Status:     model.AircraftStatusActive,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to register aircraft: %w", err)
+	}
+	if aircraft == nil {
+		return nil, fmt.Errorf("%w after registration for MAC address: %s", ErrAircraftNotFound, macAddress)
+	}
+	if aircraft.DeletedAt.Valid {
+		return nil, fmt.Errorf("%w for MAC address: %s", ErrAircraftDeleted, macAddress)
+	}
+
+	// Replace the negative cache entry
+	s.store(macAddress, aircraft)
+
+	return aircraft, nil
+}
+
+// lookup returns the cached aircraft for a MAC and whether a live entry was found
+func (s *aircraftService) lookup(macAddress string) (*model.Aircraft, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[macAddress]
+	if !ok || time.Now().After(entry.expiresAt) {
+		s.misses++
+		return nil, false
+	}
+
+	if entry.aircraft == nil {
+		s.negativeHits++
+	} else {
+		s.hits++
+	}
+	return entry.aircraft, true
+}
+
+// store caches a lookup result
+func (s *aircraftService) store(macAddress string, aircraft *model.Aircraft) {
+	ttl := s.ttl
+	if aircraft == nil {
+		ttl = s.negativeTTL
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[macAddress] = aircraftCacheEntry{aircraft: aircraft, expiresAt: time.Now().Add(ttl)}
+}
+
+// Invalidate drops the cached entry for a MAC address
+func (s *aircraftService) Invalidate(macAddress string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, macAddress)
+}
+
+// InvalidateAll drops every cached entry
+func (s *aircraftService) InvalidateAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = make(map[string]aircraftCacheEntry)
+}
+
+// CacheStats returns a snapshot of cache usage
+func (s *aircraftService) CacheStats() AircraftCacheStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := AircraftCacheStats{
+		Entries:      len(s.cache),
+		Hits:         s.hits,
+		NegativeHits: s.negativeHits,
+		Misses:       s.misses,
+	}
+	if lookups := s.hits + s.negativeHits + s.misses; lookups > 0 {
+		stats.HitRate = float64(s.hits+s.negativeHits) / float64(lookups)
+	}
+	return stats
+}
+
+// WatchChanges drops cached entries when an aircraft control message arrives and
+// periodically removes expired entries
+func (s *aircraftService) WatchChanges(ctx context.Context) {
+	go listenControlChannel(ctx, s.redisClient, s.controlChannel, s.handleControl)
+
+	ticker := time.NewTicker(s.negativeTTL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.evictExpired()
+		}
+	}
+}
+
+// handleControl drops the cached entries named by an aircraft control message
+func (s *aircraftService) handleControl(message model.ControlMessage) {
+	if message.Type != model.ControlInvalidateAircraft {
+		return
+	}
+
+	if message.MACAddress != "" {
+		s.Invalidate(message.MACAddress)
+	} else {
+		s.InvalidateAll()
+	}
+	logging.Debug("Aircraft cache invalidated", zap.String("mac_address", message.MACAddress))
+}
+
+// evictExpired removes expired entries so unknown MACs don't accumulate
+func (s *aircraftService) evictExpired() {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for macAddress, entry := range s.cache {
+		if now.After(entry.expiresAt) {
+			delete(s.cache, macAddress)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"gorm.io/gorm"
+)
+
+// expireCached makes the cached entry of a MAC address expire
+func expireCached(s AircraftService, macAddress string) {
+	service := s.(*aircraftService)
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	entry := service.cache[macAddress]
+	entry.expiresAt = time.Now().Add(-time.Second)
+	service.cache[macAddress] = entry
+}
+
+func TestAircraftCacheLookups(t *testing.T) {
+	aircraftRepo := &memoryAircraftRepo{aircraft: map[string]*model.Aircraft{
+		"aa:aa": {Model: gorm.Model{ID: 1}, MACAddress: "aa:aa", Name: "first"},
+	}}
+	s := NewAircraftService(aircraftRepo, nil, "", time.Minute, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if aircraft, err := s.GetAircraftByMAC("aa:aa"); err != nil || aircraft.ID != 1 {
+			t.Fatalf("GetAircraftByMAC(aa:aa) = %v, %v", aircraft, err)
+		}
+		// Unknown MACs are cached too, so a flood of them doesn't reach the database
+		if _, err := s.GetAircraftByMAC("bb:bb"); !errors.Is(err, ErrAircraftNotFound) {
+			t.Fatalf("GetAircraftByMAC(bb:bb) error = %v, want ErrAircraftNotFound", err)
+		}
+	}
+
+	if aircraftRepo.lookups != 2 {
+		t.Errorf("repository lookups = %d, want 2", aircraftRepo.lookups)
+	}
+	stats := s.CacheStats()
+	if stats.Entries != 2 || stats.Hits != 2 || stats.NegativeHits != 2 || stats.Misses != 2 || stats.HitRate != 4.0/6.0 {
+		t.Errorf("CacheStats() = %+v, want 2 entries, 2 hits, 2 negative hits and 2 misses", stats)
+	}
+
+	// Expired entries are looked up again
+	expireCached(s, "bb:bb")
+	if _, err := s.GetAircraftByMAC("bb:bb"); !errors.Is(err, ErrAircraftNotFound) {
+		t.Fatalf("GetAircraftByMAC(bb:bb) error = %v, want ErrAircraftNotFound", err)
+	}
+	if aircraftRepo.lookups != 3 {
+		t.Errorf("repository lookups after expiry = %d, want 3", aircraftRepo.lookups)
+	}
+}
+
+func TestAircraftCacheRegistrationReplacesNegativeEntry(t *testing.T) {
+	aircraftRepo := &memoryAircraftRepo{aircraft: make(map[string]*model.Aircraft)}
+	s := NewAircraftService(aircraftRepo, nil, "", time.Minute, time.Minute)
+
+	if _, err := s.GetAircraftByMAC("aa:aa"); !errors.Is(err, ErrAircraftNotFound) {
+		t.Fatalf("GetAircraftByMAC() error = %v, want ErrAircraftNotFound", err)
+	}
+	registered, err := s.RegisterAircraft("aa:aa", 7)
+	if err != nil {
+		t.Fatalf("RegisterAircraft() error = %v", err)
+	}
+
+	aircraft, err := s.GetAircraftByMAC("aa:aa")
+	if err != nil || aircraft != registered {
+		t.Fatalf("GetAircraftByMAC() = %v, %v, want the registered aircraft", aircraft, err)
+	}
+	if aircraftRepo.lookups != 1 {
+		t.Errorf("repository lookups = %d, want 1", aircraftRepo.lookups)
+	}
+}
+
+func TestAircraftCacheInvalidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		message     model.ControlMessage
+		wantLookups int    // Repository lookups when reading both MACs again
+		wantName    string // Name read for aa:aa
+		wantFound   bool   // Whether bb:bb, unknown when cached, is found
+	}{
+		{"single aircraft", model.ControlMessage{Type: model.ControlInvalidateAircraft, MACAddress: "aa:aa"}, 1, "renamed", false},
+		{"unknown aircraft becomes known", model.ControlMessage{Type: model.ControlInvalidateAircraft, MACAddress: "bb:bb"}, 1, "first", true},
+		{"all aircraft", model.ControlMessage{Type: model.ControlInvalidateAircraft}, 2, "renamed", true},
+		{"other message types are ignored", model.ControlMessage{Type: model.ControlInvalidateThresholds}, 0, "first", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aircraftRepo := &memoryAircraftRepo{aircraft: map[string]*model.Aircraft{
+				"aa:aa": {Model: gorm.Model{ID: 1}, MACAddress: "aa:aa", Name: "first"},
+			}}
+			s := NewAircraftService(aircraftRepo, nil, "", time.Minute, time.Minute)
+			_, _ = s.GetAircraftByMAC("aa:aa")
+			_, _ = s.GetAircraftByMAC("bb:bb")
+
+			aircraftRepo.aircraft["aa:aa"] = &model.Aircraft{Model: gorm.Model{ID: 1}, MACAddress: "aa:aa", Name: "renamed"}
+			aircraftRepo.aircraft["bb:bb"] = &model.Aircraft{Model: gorm.Model{ID: 2}, MACAddress: "bb:bb", Name: "second"}
+			aircraftRepo.lookups = 0
+
+			s.(*aircraftService).handleControl(tt.message)
+
+			aircraft, err := s.GetAircraftByMAC("aa:aa")
+			if err != nil || aircraft.Name != tt.wantName {
+				t.Errorf("GetAircraftByMAC(aa:aa) = %v, %v, want name %q", aircraft, err, tt.wantName)
+			}
+			if _, err := s.GetAircraftByMAC("bb:bb"); (err == nil) != tt.wantFound {
+				t.Errorf("GetAircraftByMAC(bb:bb) error = %v, want found = %v", err, tt.wantFound)
+			}
+			if aircraftRepo.lookups != tt.wantLookups {
+				t.Errorf("repository lookups = %d, want %d", aircraftRepo.lookups, tt.wantLookups)
+			}
+		})
+	}
+}
+
+func TestAircraftCacheEvictsExpiredEntries(t *testing.T) {
+	aircraftRepo := &memoryAircraftRepo{aircraft: make(map[string]*model.Aircraft)}
+	s := NewAircraftService(aircraftRepo, nil, "", time.Minute, time.Minute)
+	_, _ = s.GetAircraftByMAC("aa:aa")
+	_, _ = s.GetAircraftByMAC("bb:bb")
+
+	expireCached(s, "aa:aa")
+	s.(*aircraftService).evictExpired()
+
+	if stats := s.CacheStats(); stats.Entries != 1 {
+		t.Errorf("cache entries = %d, want 1", stats.Entries)
+	}
+}
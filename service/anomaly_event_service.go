@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/logging"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/publisher"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/repository"
+	"go.uber.org/zap"
+)
+
+// ErrAnomalyEventNotOpen is returned when acknowledging an incident that does not exist or is not open
+var ErrAnomalyEventNotOpen = errors.New("anomaly event not found or not open")
+
+// AnomalyEventService turns per-sample anomalies into incidents with an open → acknowledged → resolved lifecycle
+type AnomalyEventService interface {
+	Load() error
+	// Track updates the aircraft's incidents with a sample and returns the incidents that were opened,
+	// escalated or resolved, most serious first
+	Track(aircraftID uint, at time.Time, anomaly *model.Anomaly) []*model.AnomalyEvent
+	Acknowledge(ctx context.Context, id uint, acknowledgedBy string) (*model.AnomalyEvent, error)
+	List(status model.AnomalyEventStatus, limit int) ([]*model.AnomalyEvent, error)
+}
+
+// aircraftIncidents holds the unresolved incidents of one aircraft by anomaly type
+type aircraftIncidents struct {
+	mu       sync.Mutex
+	events   map[model.AnomalyType]*model.AnomalyEvent
+	resolved map[model.AnomalyType]time.Time // Time of the sample that resolved the latest incident of a type
+}
+
+// newAircraftIncidents creates the incident state of an aircraft
+func newAircraftIncidents() *aircraftIncidents {
+	return &aircraftIncidents{
+		events:   make(map[model.AnomalyType]*model.AnomalyEvent),
+		resolved: make(map[model.AnomalyType]time.Time),
+	}
+}
+
+type anomalyEventService struct {
+	anomalyEventRepo repository.AnomalyEventRepository
+	feedPublisher    publisher.FeedPublisher
+	escalateAfter    time.Duration
+	mu               sync.Mutex
+	incidents        map[uint]*aircraftIncidents // aircraft ID -> unresolved incidents
+}
+
+// NewAnomalyEventService creates a new anomaly event service.
+// An incident's severity is raised one level for every escalateAfter it stays unresolved; zero disables escalation.
+func NewAnomalyEventService(
+	anomalyEventRepo repository.AnomalyEventRepository,
+	feedPublisher publisher.FeedPublisher,
+	escalateAfter time.Duration,
+) AnomalyEventService {
+	return &anomalyEventService{
+		anomalyEventRepo: anomalyEventRepo,
+		feedPublisher:    feedPublisher,
+		escalateAfter:    escalateAfter,
+		incidents:        make(map[uint]*aircraftIncidents),
+	}
+}
+
+// Load restores unresolved incidents from the database so they continue after a restart
+func (s *anomalyEventService) Load() error {
+	events, err := s.anomalyEventRepo.GetUnresolved()
+	if err != nil {
+		return fmt.Errorf("failed to load unresolved anomaly events: %w", err)
+	}
+
+	incidents := make(map[uint]*aircraftIncidents)
+	for _, event := range events {
+		aircraft := incidents[event.AircraftID]
+		if aircraft == nil {
+			aircraft = newAircraftIncidents()
+			incidents[event.AircraftID] = aircraft
+		}
+		aircraft.events[event.AnomalyType] = event
+	}
+
+	s.mu.Lock()
+	s.incidents = incidents
+	s.mu.Unlock()
+
+	logging.Info("Anomaly incidents loaded", zap.Int("unresolved", len(events)))
+
+	return nil
+}
+
+// Track groups the sample's violations by kind. Each kind has at most one unresolved incident per aircraft;
+// an incident is resolved by the first sample without violations of its kind. An incident keeps the highest
+// severity it has reached. Late samples neither resolve an incident seen after them nor reopen one
+// resolved after them.
+func (s *anomalyEventService) Track(aircraftID uint, at time.Time, anomaly *model.Anomaly) []*model.AnomalyEvent {
+	current := make(map[model.AnomalyType][]model.Violation)
+	var order []model.AnomalyType
+	for _, violation := range anomaly.Violations {
+		anomalyType := model.AnomalyType(violation.Kind)
+		if _, ok := current[anomalyType]; !ok {
+			order = append(order, anomalyType)
+		}
+		current[anomalyType] = append(current[anomalyType], violation)
+	}
+
+	s.mu.Lock()
+	aircraft := s.incidents[aircraftID]
+	if aircraft == nil {
+		if len(current) == 0 {
+			s.mu.Unlock()
+			return nil
+		}
+		aircraft = newAircraftIncidents()
+		s.incidents[aircraftID] = aircraft
+	}
+	s.mu.Unlock()
+
+	aircraft.mu.Lock()
+	defer aircraft.mu.Unlock()
+
+	var transitions []*model.AnomalyEvent
+	for _, anomalyType := range order {
+		violations := current[anomalyType]
+
+		event, ok := aircraft.events[anomalyType]
+		if !ok {
+			if at.Before(aircraft.resolved[anomalyType]) {
+				continue
+			}
+			event = &model.AnomalyEvent{
+				AircraftID:  aircraftID,
+				AnomalyType: anomalyType,
+				Status:      model.AnomalyEventOpen,
+				Severity:    model.MaxSeverity(violations),
+				Violations:  violations,
+				FirstSeen:   at,
+				LastSeen:    at,
+				SampleCount: 1,
+			}
+			if err := s.anomalyEventRepo.Create(event); err != nil {
+				logging.Error("Failed to open anomaly event",
+					zap.Error(err),
+					zap.Uint("aircraft_id", aircraftID),
+					zap.String("anomaly_type", string(anomalyType)),
+				)
+				continue // Retried with the next anomalous sample
+			}
+			aircraft.events[anomalyType] = event
+			transitions = append(transitions, s.snapshot(event))
+			continue
+		}
+
+		event.Violations = violations
+		event.SampleCount++
+		if at.After(event.LastSeen) {
+			event.LastSeen = at
+		}
+
+		escalated := false
+		if severity := s.severityAt(event, violations, at); severity.Rank() > event.Severity.Rank() {
+			event.Severity = severity
+			escalated = true
+		}
+		if err := s.anomalyEventRepo.UpdateActivity(event); err != nil {
+			logging.Error("Failed to update anomaly event",
+				zap.Error(err),
+				zap.Uint("anomaly_event_id", event.ID),
+			)
+		}
+		if escalated {
+			transitions = append(transitions, s.snapshot(event))
+		}
+	}
+
+	for anomalyType, event := range aircraft.events {
+		if _, ok := current[anomalyType]; ok || at.Before(event.LastSeen) {
+			continue
+		}
+
+		if err := s.anomalyEventRepo.Resolve(event.ID, at); err != nil {
+			logging.Error("Failed to resolve anomaly event",
+				zap.Error(err),
+				zap.Uint("anomaly_event_id", event.ID),
+			)
+			continue // Retried with the next normal sample
+		}
+		resolvedAt := at
+		event.Status = model.AnomalyEventResolved
+		event.ResolvedAt = &resolvedAt
+		delete(aircraft.events, anomalyType)
+		aircraft.resolved[anomalyType] = at
+		transitions = append(transitions, s.snapshot(event))
+	}
+
+	sort.SliceStable(transitions, func(i, j int) bool {
+		if transitions[i].Severity != transitions[j].Severity {
+			return transitions[i].Severity.Rank() > transitions[j].Severity.Rank()
+		}
+		return transitions[i].ID < transitions[j].ID
+	})
+
+	return transitions
+}
+
+// severityAt returns the severity of the sample's violations, escalated for how long the incident has lasted
+func (s *anomalyEventService) severityAt(event *model.AnomalyEvent, violations []model.Violation, at time.Time) model.Severity {
+	severity := model.MaxSeverity(violations)
+	if s.escalateAfter > 0 {
+		// Late samples can predate the incident; they don't escalate it
+		elapsed := at.Sub(event.FirstSeen)
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		severity = severity.Escalate(int(elapsed / s.escalateAfter))
+	}
+	return severity
+}
+
+// Acknowledge marks an open incident as acknowledged and publishes the transition
+func (s *anomalyEventService) Acknowledge(ctx context.Context, id uint, acknowledgedBy string) (*model.AnomalyEvent, error) {
+	event, err := s.anomalyEventRepo.Acknowledge(id, acknowledgedBy, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to acknowledge anomaly event: %w", err)
+	}
+	if event == nil {
+		return nil, fmt.Errorf("%w: %d", ErrAnomalyEventNotOpen, id)
+	}
+
+	// Keep the tracked copy in sync so its status is published correctly on resolution
+	s.mu.Lock()
+	aircraft := s.incidents[event.AircraftID]
+	s.mu.Unlock()
+	if aircraft != nil {
+		aircraft.mu.Lock()
+		if tracked, ok := aircraft.events[event.AnomalyType]; ok && tracked.ID == event.ID {
+			tracked.Status = event.Status
+			tracked.AcknowledgedAt = event.AcknowledgedAt
+			tracked.AcknowledgedBy = event.AcknowledgedBy
+		}
+		aircraft.mu.Unlock()
+	}
+
+	if err := s.feedPublisher.PublishAnomalyEvent(ctx, event); err != nil {
+		logging.Error("Failed to publish anomaly event",
+			zap.Error(err),
+			zap.Uint("anomaly_event_id", event.ID),
+			zap.String("status", string(event.Status)),
+		)
+	}
+
+	return event, nil
+}
+
+// List retrieves the most recent incidents, optionally filtered by status
+func (s *anomalyEventService) List(status model.AnomalyEventStatus, limit int) ([]*model.AnomalyEvent, error) {
+	return s.anomalyEventRepo.List(status, limit)
+}
+
+// snapshot copies an event so it can be published after the tracked copy changes
+func (s *anomalyEventService) snapshot(event *model.AnomalyEvent) *model.AnomalyEvent {
+	copied := *event
+	return &copied
+}
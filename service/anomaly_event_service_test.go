@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+)
+
+func thresholdAnomaly(severity model.Severity) *model.Anomaly {
+	return &model.Anomaly{
+		HasAnomaly: true,
+		Violations: []model.Violation{{Kind: model.ViolationKindThreshold, Severity: severity}},
+	}
+}
+
+func TestAnomalyEventServiceEscalation(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		offset time.Duration // Second sample relative to the first
+		want   model.Severity
+	}{
+		{"same time", 0, model.SeverityInfo},
+		{"one period", 5 * time.Minute, model.SeverityWarning},
+		{"two periods", 10 * time.Minute, model.SeverityCritical},
+		{"late sample", -20 * time.Minute, model.SeverityInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewAnomalyEventService(&memoryAnomalyEventRepo{}, nil, 5*time.Minute)
+
+			if transitions := s.Track(1, start, thresholdAnomaly(model.SeverityInfo)); len(transitions) != 1 {
+				t.Fatalf("first sample: got %d transitions, want 1", len(transitions))
+			}
+			s.Track(1, start.Add(tt.offset), thresholdAnomaly(model.SeverityInfo))
+
+			events := s.(*anomalyEventService).incidents[1].events
+			event := events[model.AnomalyTypeThreshold]
+			if event == nil {
+				t.Fatal("incident was resolved")
+			}
+			if event.Severity != tt.want {
+				t.Errorf("severity = %q, want %q", event.Severity, tt.want)
+			}
+		})
+	}
+}
+
+// incidentStatuses returns the statuses of incident transitions
+func incidentStatuses(transitions []*model.AnomalyEvent) []model.AnomalyEventStatus {
+	var statuses []model.AnomalyEventStatus
+	for _, event := range transitions {
+		statuses = append(statuses, event.Status)
+	}
+	return statuses
+}
+
+func equalStatuses(a, b []model.AnomalyEventStatus) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAnomalyEventServiceLifecycle(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	open, resolved := model.AnomalyEventOpen, model.AnomalyEventResolved
+
+	type step struct {
+		at          time.Duration // Relative to start
+		anomalous   bool
+		failCreate  bool
+		failResolve bool
+		want        []model.AnomalyEventStatus
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "open and resolve on a normal sample",
+			steps: []step{
+				{at: 0, anomalous: true, want: []model.AnomalyEventStatus{open}},
+				{at: 10 * time.Second, anomalous: true},
+				{at: 20 * time.Second, want: []model.AnomalyEventStatus{resolved}},
+				{at: 30 * time.Second},
+			},
+		},
+		{
+			name: "normal samples without an incident",
+			steps: []step{
+				{at: 0},
+				{at: 10 * time.Second},
+			},
+		},
+		{
+			name: "late normal sample does not resolve",
+			steps: []step{
+				{at: 0, anomalous: true, want: []model.AnomalyEventStatus{open}},
+				{at: 20 * time.Second, anomalous: true},
+				{at: 10 * time.Second},
+				{at: 30 * time.Second, want: []model.AnomalyEventStatus{resolved}},
+			},
+		},
+		{
+			name: "late anomalous sample does not reopen",
+			steps: []step{
+				{at: 0, anomalous: true, want: []model.AnomalyEventStatus{open}},
+				{at: 20 * time.Second, want: []model.AnomalyEventStatus{resolved}},
+				{at: 10 * time.Second, anomalous: true},
+				{at: 30 * time.Second, anomalous: true, want: []model.AnomalyEventStatus{open}},
+			},
+		},
+		{
+			name: "failed create is retried on the next sample",
+			steps: []step{
+				{at: 0, anomalous: true, failCreate: true},
+				{at: 10 * time.Second, anomalous: true, want: []model.AnomalyEventStatus{open}},
+			},
+		},
+		{
+			name: "failed resolve is retried on the next sample",
+			steps: []step{
+				{at: 0, anomalous: true, want: []model.AnomalyEventStatus{open}},
+				{at: 10 * time.Second, failResolve: true},
+				{at: 20 * time.Second, want: []model.AnomalyEventStatus{resolved}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryAnomalyEventRepo{}
+			s := NewAnomalyEventService(repo, nil, 0)
+
+			for i, step := range tt.steps {
+				repo.createErr, repo.resolveErr = nil, nil
+				if step.failCreate {
+					repo.createErr = errors.New("database unavailable")
+				}
+				if step.failResolve {
+					repo.resolveErr = errors.New("database unavailable")
+				}
+
+				anomaly := &model.Anomaly{}
+				if step.anomalous {
+					anomaly = thresholdAnomaly(model.SeverityWarning)
+				}
+				transitions := s.Track(1, start.Add(step.at), anomaly)
+				if got := incidentStatuses(transitions); !equalStatuses(got, step.want) {
+					t.Errorf("step %d: transitions = %v, want %v", i, got, step.want)
+				}
+				for _, event := range transitions {
+					if event.ResolvedAt != nil && event.ResolvedAt.Before(event.LastSeen) {
+						t.Errorf("step %d: resolved at %v, before last seen %v", i, event.ResolvedAt, event.LastSeen)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAnomalyEventServiceAcknowledge(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		id          uint
+		acknowledge int // Times the incident is acknowledged
+		wantErr     bool
+	}{
+		{"open incident", 1, 1, false},
+		{"unknown incident", 2, 1, true},
+		{"already acknowledged", 1, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := &recordingPublisher{}
+			s := NewAnomalyEventService(&memoryAnomalyEventRepo{}, feed, 0)
+			s.Track(1, start, thresholdAnomaly(model.SeverityWarning))
+
+			var event *model.AnomalyEvent
+			var err error
+			for i := 0; i < tt.acknowledge; i++ {
+				event, err = s.Acknowledge(context.Background(), tt.id, "operator")
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrAnomalyEventNotOpen) {
+					t.Fatalf("Acknowledge() error = %v, want ErrAnomalyEventNotOpen", err)
+				}
+				if len(feed.anomalyEvents) != tt.acknowledge-1 {
+					t.Errorf("published %d events, want %d", len(feed.anomalyEvents), tt.acknowledge-1)
+				}
+				return
+			}
+			if err != nil || event.Status != model.AnomalyEventAcknowledged {
+				t.Fatalf("Acknowledge() = %v, %v; want an acknowledged incident", event, err)
+			}
+			if len(feed.anomalyEvents) != 1 {
+				t.Errorf("published %d events, want 1", len(feed.anomalyEvents))
+			}
+
+			// The tracked copy is updated, so the resolution carries the acknowledgement
+			tracked := s.(*anomalyEventService).incidents[1].events[model.AnomalyTypeThreshold]
+			if tracked.Status != model.AnomalyEventAcknowledged || tracked.AcknowledgedBy != "operator" {
+				t.Errorf("tracked incident = %q by %q, want acknowledged by operator", tracked.Status, tracked.AcknowledgedBy)
+			}
+			transitions := s.Track(1, start.Add(time.Second), &model.Anomaly{})
+			if len(transitions) != 1 || transitions[0].AcknowledgedBy != "operator" {
+				t.Errorf("resolution = %v, want the acknowledged incident resolved", transitions)
+			}
+		})
+	}
+}
+
+func TestAnomalyEventServiceLoad(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		aircraft  uint
+		anomalous bool
+		want      []model.AnomalyEventStatus
+		wantID    uint
+	}{
+		{"loaded incident continues", 1, true, nil, 0},
+		{"loaded incident resolves", 1, false, []model.AnomalyEventStatus{model.AnomalyEventResolved}, 5},
+		{"other aircraft open their own", 2, true, []model.AnomalyEventStatus{model.AnomalyEventOpen}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unresolved := []*model.AnomalyEvent{{
+				ID: 5, AircraftID: 1, AnomalyType: model.AnomalyTypeThreshold, Status: model.AnomalyEventOpen,
+				Severity: model.SeverityWarning, FirstSeen: start, LastSeen: start, SampleCount: 3,
+			}}
+			s := NewAnomalyEventService(&memoryAnomalyEventRepo{unresolved: unresolved}, nil, 0)
+			if err := s.Load(); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			anomaly := &model.Anomaly{}
+			if tt.anomalous {
+				anomaly = thresholdAnomaly(model.SeverityWarning)
+			}
+			transitions := s.Track(tt.aircraft, start.Add(time.Second), anomaly)
+			if got := incidentStatuses(transitions); !equalStatuses(got, tt.want) {
+				t.Fatalf("transitions = %v, want %v", got, tt.want)
+			}
+			if len(transitions) == 1 && transitions[0].ID != tt.wantID {
+				t.Errorf("incident ID = %d, want %d", transitions[0].ID, tt.wantID)
+			}
+		})
+	}
+}
@@ -1,71 +1,133 @@
 package service
 
 import (
+	"fmt"
+	"sort"
+	"time"
+
 	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
 )
 
 // AnomalyService handles anomaly detection by combining threshold and geofence checks
 type AnomalyService interface {
-	DetectAnomaly(aircraftID uint, telemetry *model.TelemetryDTO) *model.Anomaly
+	DetectAnomaly(aircraft *model.Aircraft, telemetry *model.TelemetryDTO) *model.Anomaly
 }
 
 type anomalyService struct {
 	thresholdService ThresholdService
 	geofenceService  GeofenceService
+	lookAhead        time.Duration
+	movementSpeed    float64
 }
 
-// NewAnomalyService creates a new anomaly service
-func NewAnomalyService(thresholdService ThresholdService, geofenceService GeofenceService) AnomalyService {
+// NewAnomalyService creates a new anomaly service.
+// lookAhead is how far ahead tracks are projected for approaching geofences; zero disables the prediction.
+// Grounded aircraft reporting a ground speed above movementSpeed raise an unauthorized movement anomaly.
+func NewAnomalyService(
+	thresholdService ThresholdService,
+	geofenceService GeofenceService,
+	lookAhead time.Duration,
+	movementSpeed float64,
+) AnomalyService {
 	return &anomalyService{
 		thresholdService: thresholdService,
 		geofenceService:  geofenceService,
+		lookAhead:        lookAhead,
+		movementSpeed:    movementSpeed,
 	}
 }
 
-// DetectAnomaly detects anomalies by checking thresholds and geofences
-func (s *anomalyService) DetectAnomaly(aircraftID uint, telemetry *model.TelemetryDTO) *model.Anomaly {
+// DetectAnomaly detects anomalies by checking aircraft status, thresholds and geofences
+func (s *anomalyService) DetectAnomaly(aircraft *model.Aircraft, telemetry *model.TelemetryDTO) *model.Anomaly {
 	// Check thresholds
-	hasThresholdViolation, thresholdViolations := s.thresholdService.CheckThresholds(aircraftID, telemetry)
+	hasThresholdViolation, thresholdViolations := s.thresholdService.CheckThresholds(aircraft.ID, telemetry)
 
 	// Check geofences
-	hasGeofenceViolation, violatingGeofences := s.geofenceService.CheckGeofences(telemetry.Latitude, telemetry.Longitude)
+	hasGeofenceViolation, violatingGeofences := s.geofenceService.CheckGeofences(telemetry)
 
-	// Determine anomaly type
-	var anomalyType model.AnomalyType
-	var details string
+	// Predict geofences on the projected track
+	var approaches []model.GeofenceApproach
+	if s.lookAhead > 0 {
+		approaches = s.geofenceService.PredictApproaches(telemetry, s.lookAhead)
+	}
+	hasApproach := len(approaches) > 0
 
-	hasAnomaly := hasThresholdViolation || hasGeofenceViolation
+	// Check for movement of grounded aircraft
+	hasUnauthorizedMovement := aircraft.Status == model.AircraftStatusGrounded && telemetry.GroundSpeed > s.movementSpeed
 
+	hasAnomaly := hasThresholdViolation || hasGeofenceViolation || hasApproach || hasUnauthorizedMovement
 	if !hasAnomaly {
 		return &model.Anomaly{
 			HasAnomaly: false,
 		}
 	}
 
-	if hasThresholdViolation && hasGeofenceViolation {
+	// Collect violations by kind; thresholds come in metric order,
+	// geofences by ID and approaches by time to entry
+	var violations []model.Violation
+	if hasUnauthorizedMovement {
+		groundSpeed, limit := telemetry.GroundSpeed, s.movementSpeed
+		violations = append(violations, model.Violation{
+			Kind:     model.ViolationKindUnauthorizedMovement,
+			Severity: model.SeverityCritical,
+			Metric:   string(model.MetricGroundSpeed),
+			Observed: &groundSpeed,
+			Limit:    &limit,
+			Bound:    model.BoundTypeMax,
+			Message:  fmt.Sprintf("Grounded aircraft moving at %.2f", groundSpeed),
+		})
+	}
+	violations = append(violations, thresholdViolations...)
+	for _, geofence := range violatingGeofences {
+		geofenceID := geofence.ID
+		violations = append(violations, model.Violation{
+			Kind:         model.ViolationKindGeofence,
+			Severity:     model.SeverityCritical,
+			GeofenceID:   &geofenceID,
+			GeofenceName: geofence.Name,
+			Message:      "Inside restricted area: " + geofence.Name,
+		})
+	}
+	for _, approach := range approaches {
+		geofenceID, timeToEntry := approach.GeofenceID, approach.TimeToEntrySeconds
+		violations = append(violations, model.Violation{
+			Kind:               model.ViolationKindApproachingGeofence,
+			Severity:           model.SeverityWarning,
+			GeofenceID:         &geofenceID,
+			GeofenceName:       approach.GeofenceName,
+			TimeToEntrySeconds: &timeToEntry,
+			Message:            fmt.Sprintf("Approaching restricted area: %s in %.0fs", approach.GeofenceName, timeToEntry),
+		})
+	}
+
+	// Most serious first, keeping the order above within a severity
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Severity.Rank() > violations[j].Severity.Rank()
+	})
+
+	// Determine anomaly type
+	var anomalyType model.AnomalyType
+	switch {
+	case hasUnauthorizedMovement:
+		// Takes precedence: a grounded aircraft should not be moving at all
+		anomalyType = model.AnomalyTypeUnauthorizedMovement
+	case hasThresholdViolation && hasGeofenceViolation:
 		anomalyType = model.AnomalyTypeBoth
-		details = "Threshold and geofence violations detected"
-		if len(thresholdViolations) > 0 {
-			details += ": " + thresholdViolations[0]
-		}
-		if len(violatingGeofences) > 0 {
-			details += " - Inside geofence: " + violatingGeofences[0].Name
-		}
-	} else if hasThresholdViolation {
+	case hasThresholdViolation:
 		anomalyType = model.AnomalyTypeThreshold
-		if len(thresholdViolations) > 0 {
-			details = thresholdViolations[0]
-		}
-	} else if hasGeofenceViolation {
+	case hasGeofenceViolation:
 		anomalyType = model.AnomalyTypeGeofence
-		if len(violatingGeofences) > 0 {
-			details = "Inside restricted area: " + violatingGeofences[0].Name
-		}
+	default:
+		anomalyType = model.AnomalyTypeApproachingGeofence
 	}
 
 	return &model.Anomaly{
 		HasAnomaly:  hasAnomaly,
 		AnomalyType: anomalyType,
-		Details:     details,
+		Severity:    model.MaxSeverity(violations),
+		Details:     model.JoinViolationMessages(violations),
+		Violations:  violations,
+		Approaches:  approaches,
+		Geofences:   violatingGeofences,
 	}
 }
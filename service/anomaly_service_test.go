@@ -0,0 +1,136 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+)
+
+// violationLabel identifies a violation by severity, kind and metric or geofence
+func violationLabel(v model.Violation) string {
+	if v.GeofenceName != "" {
+		return fmt.Sprintf("%s %s %s", v.Severity, v.Kind, v.GeofenceName)
+	}
+	return fmt.Sprintf("%s %s %s", v.Severity, v.Kind, v.Metric)
+}
+
+func thresholdBreach(metric model.MetricName, severity model.Severity) model.Violation {
+	return model.Violation{Kind: model.ViolationKindThreshold, Severity: severity, Metric: string(metric)}
+}
+
+func TestAnomalyServiceViolationOrder(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       model.AircraftStatus
+		groundSpeed  float64
+		thresholds   staticThresholdService
+		geofences    []*model.Geofence
+		approaches   []model.GeofenceApproach
+		wantType     model.AnomalyType
+		wantSeverity model.Severity
+		want         []string
+	}{
+		{
+			name:        "critical before warning before info, metric order kept",
+			groundSpeed: 100,
+			thresholds: staticThresholdService{
+				thresholdBreach(model.MetricGroundSpeed, model.SeverityInfo),
+				thresholdBreach(model.MetricAltitude, model.SeverityWarning),
+				thresholdBreach(model.MetricClimbRate, model.SeverityCritical),
+				thresholdBreach(model.MetricHeading, model.SeverityWarning),
+			},
+			wantType:     model.AnomalyTypeThreshold,
+			wantSeverity: model.SeverityCritical,
+			want: []string{
+				"critical threshold climb_rate",
+				"warning threshold altitude",
+				"warning threshold heading",
+				"info threshold ground_speed",
+			},
+		},
+		{
+			name:        "geofences rank with critical thresholds, approaches with warnings",
+			groundSpeed: 100,
+			thresholds: staticThresholdService{
+				thresholdBreach(model.MetricGroundSpeed, model.SeverityWarning),
+				thresholdBreach(model.MetricAltitude, model.SeverityCritical),
+			},
+			geofences: []*model.Geofence{{Name: "alpha"}, {Name: "bravo"}},
+			approaches: []model.GeofenceApproach{
+				{GeofenceID: 3, GeofenceName: "charlie", TimeToEntrySeconds: 30},
+				{GeofenceID: 4, GeofenceName: "delta", TimeToEntrySeconds: 90},
+			},
+			wantType:     model.AnomalyTypeBoth,
+			wantSeverity: model.SeverityCritical,
+			want: []string{
+				"critical threshold altitude",
+				"critical geofence alpha",
+				"critical geofence bravo",
+				"warning threshold ground_speed",
+				"warning approaching_geofence charlie",
+				"warning approaching_geofence delta",
+			},
+		},
+		{
+			name:        "unauthorized movement first",
+			status:      model.AircraftStatusGrounded,
+			groundSpeed: 20,
+			thresholds: staticThresholdService{
+				thresholdBreach(model.MetricAltitude, model.SeverityCritical),
+			},
+			geofences:    []*model.Geofence{{Name: "alpha"}},
+			wantType:     model.AnomalyTypeUnauthorizedMovement,
+			wantSeverity: model.SeverityCritical,
+			want: []string{
+				"critical unauthorized_movement ground_speed",
+				"critical threshold altitude",
+				"critical geofence alpha",
+			},
+		},
+		{
+			name:         "approach only",
+			groundSpeed:  100,
+			approaches:   []model.GeofenceApproach{{GeofenceID: 3, GeofenceName: "charlie", TimeToEntrySeconds: 30}},
+			wantType:     model.AnomalyTypeApproachingGeofence,
+			wantSeverity: model.SeverityWarning,
+			want:         []string{"warning approaching_geofence charlie"},
+		},
+		{
+			name:        "grounded and stationary",
+			status:      model.AircraftStatusGrounded,
+			groundSpeed: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewAnomalyService(
+				tt.thresholds,
+				&staticGeofenceService{geofences: tt.geofences, approaches: tt.approaches},
+				time.Minute,
+				5,
+			)
+			telemetry := positionAt(time.Now())
+			telemetry.GroundSpeed = tt.groundSpeed
+
+			anomaly := s.DetectAnomaly(&model.Aircraft{Status: tt.status}, telemetry)
+
+			if anomaly.HasAnomaly != (len(tt.want) > 0) {
+				t.Fatalf("HasAnomaly = %v, want %v", anomaly.HasAnomaly, len(tt.want) > 0)
+			}
+			if anomaly.AnomalyType != tt.wantType || anomaly.Severity != tt.wantSeverity {
+				t.Errorf("anomaly = %s/%s, want %s/%s", anomaly.AnomalyType, anomaly.Severity, tt.wantType, tt.wantSeverity)
+			}
+			if len(anomaly.Violations) != len(tt.want) {
+				t.Fatalf("violations = %d, want %d", len(anomaly.Violations), len(tt.want))
+			}
+			for i, violation := range anomaly.Violations {
+				if got := violationLabel(violation); got != tt.want[i] {
+					t.Errorf("violation %d = %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
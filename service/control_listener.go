@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/logging"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/redis"
+	"go.uber.org/zap"
+)
+
+// listenControlChannel delivers control messages from the Redis Pub/Sub channel to handle until ctx is done.
+// Messages are lost while the subscription is reconnecting, so callers should also refresh periodically.
+func listenControlChannel(ctx context.Context, redisClient redis.Client, channel string, handle func(message model.ControlMessage)) {
+	pubsub := redisClient.SubscribeToChannel(ctx, channel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var message model.ControlMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+				logging.Warn("Ignoring malformed control message",
+					zap.String("channel", channel),
+					zap.String("payload", msg.Payload),
+					zap.Error(err),
+				)
+				continue
+			}
+			handle(message)
+		}
+	}
+}
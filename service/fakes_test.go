@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/consumer"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/logging"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestMain sets up the logger once for every service test
+func TestMain(m *testing.M) {
+	logging.CreateLogger(zapcore.ErrorLevel)
+	os.Exit(m.Run())
+}
+
+// memoryTelemetryRepo is an in-memory TelemetryRepository that records writes
+type memoryTelemetryRepo struct {
+	mu       sync.Mutex
+	rows     map[uint]bool           // Aircraft IDs of written rows
+	batchErr error                   // Returned by CreateBatch
+	rowErrs  map[uint]error          // Returned by Create per aircraft ID
+	written  chan []*model.Telemetry // Receives each successful batch, if set
+}
+
+func (r *memoryTelemetryRepo) Create(_ context.Context, telemetry *model.Telemetry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.rowErrs[telemetry.AircraftID]; err != nil {
+		return err
+	}
+	r.rows[telemetry.AircraftID] = true
+	return nil
+}
+
+func (r *memoryTelemetryRepo) CreateBatch(_ context.Context, telemetries []*model.Telemetry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.batchErr != nil {
+		return r.batchErr
+	}
+	for _, telemetry := range telemetries {
+		r.rows[telemetry.AircraftID] = true
+	}
+	if r.written != nil {
+		r.written <- telemetries
+	}
+	return nil
+}
+
+// memoryAnomalyEventRepo is an in-memory AnomalyEventRepository
+type memoryAnomalyEventRepo struct {
+	nextID     uint
+	events     map[uint]*model.AnomalyEvent // Copies of the created events by ID
+	unresolved []*model.AnomalyEvent        // Returned by GetUnresolved
+	createErr  error                        // Returned by Create
+	resolveErr error                        // Returned by Resolve
+}
+
+func (r *memoryAnomalyEventRepo) Create(event *model.AnomalyEvent) error {
+	if r.createErr != nil {
+		return r.createErr
+	}
+	r.nextID++
+	event.ID = r.nextID
+	if r.events == nil {
+		r.events = make(map[uint]*model.AnomalyEvent)
+	}
+	stored := *event
+	r.events[event.ID] = &stored
+	return nil
+}
+func (r *memoryAnomalyEventRepo) UpdateActivity(*model.AnomalyEvent) error { return nil }
+func (r *memoryAnomalyEventRepo) Acknowledge(id uint, acknowledgedBy string, at time.Time) (*model.AnomalyEvent, error) {
+	event, ok := r.events[id]
+	if !ok || event.Status != model.AnomalyEventOpen {
+		return nil, nil
+	}
+	event.Status = model.AnomalyEventAcknowledged
+	event.AcknowledgedAt = &at
+	event.AcknowledgedBy = acknowledgedBy
+	acknowledged := *event
+	return &acknowledged, nil
+}
+func (r *memoryAnomalyEventRepo) Resolve(id uint, at time.Time) error {
+	if r.resolveErr != nil {
+		return r.resolveErr
+	}
+	if event, ok := r.events[id]; ok {
+		event.Status = model.AnomalyEventResolved
+		event.ResolvedAt = &at
+	}
+	return nil
+}
+func (r *memoryAnomalyEventRepo) GetUnresolved() ([]*model.AnomalyEvent, error) {
+	return r.unresolved, nil
+}
+func (r *memoryAnomalyEventRepo) List(model.AnomalyEventStatus, int) ([]*model.AnomalyEvent, error) {
+	return nil, nil
+}
+
+// memoryThresholdRepo is an in-memory ThresholdRepository holding one threshold per metric for every aircraft
+type memoryThresholdRepo map[string]*model.Threshold
+
+func (r memoryThresholdRepo) GetByAircraftID(uint) ([]*model.Threshold, error) { return nil, nil }
+func (r memoryThresholdRepo) GetDefaults() ([]*model.Threshold, error)         { return nil, nil }
+func (r memoryThresholdRepo) GetAll() ([]*model.Threshold, error)              { return nil, nil }
+func (r memoryThresholdRepo) GetByAircraftIDAndMetric(_ uint, metricName string) (*model.Threshold, error) {
+	return r[metricName], nil
+}
+
+// thresholdTable is an in-memory ThresholdRepository over a list of rows, like the thresholds table
+type thresholdTable struct {
+	mu    sync.Mutex
+	rows  []*model.Threshold
+	loads int   // GetAll and GetByAircraftID calls
+	err   error // Returned by every method
+}
+
+func (r *thresholdTable) set(rows ...*model.Threshold) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rows = rows
+}
+
+func (r *thresholdTable) GetByAircraftID(aircraftID uint) ([]*model.Threshold, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loads++
+	if r.err != nil {
+		return nil, r.err
+	}
+	var thresholds []*model.Threshold
+	for _, threshold := range r.rows {
+		if threshold.AircraftID != nil && *threshold.AircraftID == aircraftID {
+			thresholds = append(thresholds, threshold)
+		}
+	}
+	return thresholds, nil
+}
+
+func (r *thresholdTable) GetDefaults() ([]*model.Threshold, error) {
+	return nil, r.err
+}
+
+func (r *thresholdTable) GetAll() ([]*model.Threshold, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loads++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return append([]*model.Threshold(nil), r.rows...), nil
+}
+
+func (r *thresholdTable) GetByAircraftIDAndMetric(uint, string) (*model.Threshold, error) {
+	return nil, r.err
+}
+
+// memoryGeofenceEventRepo is an in-memory GeofenceEventRepository
+type memoryGeofenceEventRepo struct {
+	mu      sync.Mutex
+	saved   []*model.GeofenceEvent
+	open    []*model.GeofenceEvent // Returned by GetOpen
+	saveErr error                  // Returned by CreateBatch
+}
+
+func (r *memoryGeofenceEventRepo) CreateBatch(events []*model.GeofenceEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(events) == 0 {
+		return nil
+	}
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	r.saved = append(r.saved, events...)
+	return nil
+}
+
+func (r *memoryGeofenceEventRepo) GetOpen() ([]*model.GeofenceEvent, error) { return r.open, nil }
+
+// memoryAircraftRepo is an in-memory AircraftRepository keyed by MAC address.
+// Deleted aircraft are hidden from lookups, like soft-deleted rows.
+type memoryAircraftRepo struct {
+	mu       sync.Mutex
+	aircraft map[string]*model.Aircraft
+	lookups  int   // GetByMACAddress calls
+	err      error // Returned by every method
+}
+
+func (r *memoryAircraftRepo) GetByMACAddress(macAddress string) (*model.Aircraft, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lookups++
+	if r.err != nil {
+		return nil, r.err
+	}
+	aircraft := r.aircraft[macAddress]
+	if aircraft == nil || aircraft.DeletedAt.Valid {
+		return nil, nil
+	}
+	return aircraft, nil
+}
+
+func (r *memoryAircraftRepo) GetByID(id uint) (*model.Aircraft, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, aircraft := range r.aircraft {
+		if aircraft.ID == id && !aircraft.DeletedAt.Valid {
+			return aircraft, nil
+		}
+	}
+	return nil, r.err
+}
+
+func (r *memoryAircraftRepo) CreateIfNotExists(aircraft *model.Aircraft) (*model.Aircraft, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if stored, ok := r.aircraft[aircraft.MACAddress]; ok {
+		return stored, nil
+	}
+	aircraft.ID = uint(len(r.aircraft) + 1)
+	r.aircraft[aircraft.MACAddress] = aircraft
+	return aircraft, nil
+}
+
+// memoryUnknownAircraftRepo is an in-memory UnknownAircraftRepository
+type memoryUnknownAircraftRepo struct {
+	sightings map[string]int64 // MAC address -> message count
+}
+
+func (r *memoryUnknownAircraftRepo) RecordSighting(sighting *model.UnknownAircraftSighting) error {
+	r.sightings[sighting.MACAddress]++
+	sighting.MessageCount = r.sightings[sighting.MACAddress]
+	return nil
+}
+
+// memoryQuarantineRepo is an in-memory QuarantineRepository
+type memoryQuarantineRepo struct {
+	rows []*model.QuarantinedTelemetry
+}
+
+func (r *memoryQuarantineRepo) Create(telemetry *model.QuarantinedTelemetry) error {
+	r.rows = append(r.rows, telemetry)
+	return nil
+}
+
+// staticThresholdService is a ThresholdService that reports the same violations for every sample
+type staticThresholdService []model.Violation
+
+func (s staticThresholdService) CheckThresholds(uint, *model.TelemetryDTO) (bool, []model.Violation) {
+	return len(s) > 0, s
+}
+
+// staticGeofenceService is a GeofenceService that reports the same geofences and approaches for every point
+type staticGeofenceService struct {
+	geofences  []*model.Geofence
+	approaches []model.GeofenceApproach
+}
+
+func (s *staticGeofenceService) CheckGeofences(*model.TelemetryDTO) (bool, []*model.Geofence) {
+	return len(s.geofences) > 0, s.geofences
+}
+
+func (s *staticGeofenceService) PredictApproaches(*model.TelemetryDTO, time.Duration) []model.GeofenceApproach {
+	return s.approaches
+}
+
+func (s *staticGeofenceService) Refresh() error               { return nil }
+func (s *staticGeofenceService) WatchChanges(context.Context) {}
+
+// inlineBatcher is a TelemetryBatcher that writes each row as it is added and runs its post-commit work right away
+type inlineBatcher struct {
+	telemetryRepo   *memoryTelemetryRepo
+	added           []*model.Telemetry
+	afterCommitErrs []error
+}
+
+func (b *inlineBatcher) Run(context.Context) {}
+func (b *inlineBatcher) Stop()               {}
+
+func (b *inlineBatcher) Add(ctx context.Context, _ *consumer.StreamEntry, telemetry *model.Telemetry, afterCommit AfterCommitFunc) error {
+	b.added = append(b.added, telemetry)
+	err := b.telemetryRepo.Create(ctx, telemetry)
+	b.afterCommitErrs = append(b.afterCommitErrs, afterCommit(ctx, err))
+	return nil
+}
+
+// recordingPublisher is a FeedPublisher that records what was published
+type recordingPublisher struct {
+	mu             sync.Mutex
+	telemetry      int
+	alerts         int
+	geofenceEvents []*model.GeofenceEvent
+	unknown        []*model.UnknownAircraftSighting
+	anomalyEvents  []*model.AnomalyEvent
+}
+
+func (p *recordingPublisher) PublishGlobalTelemetry(context.Context, *model.Telemetry) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.telemetry++
+	return nil
+}
+
+func (p *recordingPublisher) PublishAlert(context.Context, *model.Telemetry, *model.Anomaly) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.alerts++
+	return nil
+}
+
+func (p *recordingPublisher) PublishGeofenceEvent(_ context.Context, event *model.GeofenceEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.geofenceEvents = append(p.geofenceEvents, event)
+	return nil
+}
+
+func (p *recordingPublisher) PublishUnknownAircraft(_ context.Context, sighting *model.UnknownAircraftSighting) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.unknown = append(p.unknown, sighting)
+	return nil
+}
+
+func (p *recordingPublisher) PublishAnomalyEvent(_ context.Context, event *model.AnomalyEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.anomalyEvents = append(p.anomalyEvents, event)
+	return nil
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"math"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+)
+
+const (
+	// defaultGeofenceCellSize is the grid cell size in degrees (about 111 km of latitude)
+	defaultGeofenceCellSize = 1.0
+	// maxGeofenceCells caps how many cells a single geofence is registered in; larger
+	// geofences are kept in a separate list that is checked for every point
+	maxGeofenceCells = 4096
+	// geofenceBoundsMargin widens indexed bounding boxes, in degrees, so points that polygon
+	// containment accepts on a boundary lying exactly on a cell edge still find the geofence
+	geofenceBoundsMargin = 1e-9
+)
+
+// cellKey identifies a grid cell by latitude and longitude index
+type cellKey struct {
+	lat int
+	lon int
+}
+
+// geofenceIndex is an immutable uniform grid over geofence bounding boxes. A lookup returns
+// the geofences whose bounding box overlaps the point's cell, so the cost per point depends
+// on local density rather than the total number of geofences.
+type geofenceIndex struct {
+	cellSize float64
+	lonCells int
+	cells    map[cellKey][]*model.Geofence
+	large    []*model.Geofence
+	size     int
+}
+
+// newGeofenceIndex builds a grid index over the given geofences
+func newGeofenceIndex(geofences []*model.Geofence, cellSize float64) *geofenceIndex {
+	if cellSize <= 0 {
+		cellSize = defaultGeofenceCellSize
+	}
+
+	idx := &geofenceIndex{
+		cellSize: cellSize,
+		lonCells: int(math.Ceil(360 / cellSize)),
+		cells:    make(map[cellKey][]*model.Geofence),
+		size:     len(geofences),
+	}
+
+	for _, geofence := range geofences {
+		idx.insert(geofence)
+	}
+
+	return idx
+}
+
+// insert registers a geofence in every cell its bounding box overlaps
+func (idx *geofenceIndex) insert(geofence *model.Geofence) {
+	minLat, maxLat, minLon, maxLon := geofence.Bounds()
+	minLat, maxLat = minLat-geofenceBoundsMargin, maxLat+geofenceBoundsMargin
+	if minLon != -180 || maxLon != 180 {
+		minLon, maxLon = minLon-geofenceBoundsMargin, maxLon+geofenceBoundsMargin
+	}
+
+	// Bounding boxes crossing the antimeridian are split in two longitude ranges
+	lonRanges := [][2]float64{{minLon, maxLon}}
+	if minLon > maxLon {
+		lonRanges = [][2]float64{{minLon, 180}, {-180, maxLon}}
+	}
+
+	minLatCell, maxLatCell := idx.latCell(minLat), idx.latCell(maxLat)
+	cellCount := 0
+	for _, lonRange := range lonRanges {
+		cellCount += (maxLatCell - minLatCell + 1) * (idx.lonCell(lonRange[1]) - idx.lonCell(lonRange[0]) + 1)
+	}
+	if cellCount > maxGeofenceCells || cellCount <= 0 {
+		idx.large = append(idx.large, geofence)
+		return
+	}
+
+	for _, lonRange := range lonRanges {
+		minLonCell, maxLonCell := idx.lonCell(lonRange[0]), idx.lonCell(lonRange[1])
+		for latCell := minLatCell; latCell <= maxLatCell; latCell++ {
+			for lonCell := minLonCell; lonCell <= maxLonCell; lonCell++ {
+				key := cellKey{lat: latCell, lon: lonCell}
+				idx.cells[key] = append(idx.cells[key], geofence)
+			}
+		}
+	}
+}
+
+// candidates returns the geofences whose bounding box may contain the point
+func (idx *geofenceIndex) candidates(lat, lon float64) []*model.Geofence {
+	cell := idx.cells[idx.cellOf(lat, lon)]
+	if len(idx.large) == 0 {
+		return cell
+	}
+
+	candidates := make([]*model.Geofence, 0, len(cell)+len(idx.large))
+	candidates = append(candidates, cell...)
+	return append(candidates, idx.large...)
+}
+
+// cellOf returns the grid cell containing a point
+func (idx *geofenceIndex) cellOf(lat, lon float64) cellKey {
+	return cellKey{lat: idx.latCell(lat), lon: idx.lonCell(model.NormalizeLongitude(lon))}
+}
+
+// latCell returns the grid row for a latitude
+func (idx *geofenceIndex) latCell(lat float64) int {
+	return int(math.Floor((math.Max(-90, math.Min(90, lat)) + 90) / idx.cellSize))
+}
+
+// lonCell returns the grid column for a longitude in [-180, 180]
+func (idx *geofenceIndex) lonCell(lon float64) int {
+	cell := int(math.Floor((lon + 180) / idx.cellSize))
+	if cell >= idx.lonCells {
+		cell = idx.lonCells - 1
+	}
+	if cell < 0 {
+		cell = 0
+	}
+	return cell
+}
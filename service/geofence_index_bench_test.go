@@ -0,0 +1,78 @@
+package service
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+)
+
+const benchGeofenceCount = 10000
+
+// benchGeofences returns a deterministic mix of small rectangles and circles spread over the globe
+func benchGeofences(n int) []*model.Geofence {
+	rng := rand.New(rand.NewSource(42))
+	geofences := make([]*model.Geofence, n)
+	for i := range geofences {
+		lat := rng.Float64()*160 - 80
+		lon := rng.Float64()*360 - 180
+		size := 0.05 + rng.Float64()*0.5
+
+		geofence := &model.Geofence{IsActive: true}
+		if i%2 == 0 {
+			geofence.Shape = model.GeofenceShapeRectangle
+			geofence.MinLatitude, geofence.MaxLatitude = lat, lat+size
+			geofence.MinLongitude, geofence.MaxLongitude = lon, model.NormalizeLongitude(lon+size)
+		} else {
+			radius := size * 50
+			geofence.Shape = model.GeofenceShapeCircle
+			geofence.CenterLatitude, geofence.CenterLongitude = &lat, &lon
+			geofence.Radius = &radius
+			geofence.RadiusUnit = model.RadiusUnitNauticalMiles
+		}
+		_ = geofence.BeforeSave(nil)
+		geofences[i] = geofence
+	}
+	return geofences
+}
+
+// benchPoints returns deterministic telemetry positions
+func benchPoints(n int) []*model.TelemetryDTO {
+	rng := rand.New(rand.NewSource(7))
+	now := time.Now()
+	points := make([]*model.TelemetryDTO, n)
+	for i := range points {
+		points[i] = &model.TelemetryDTO{
+			Timestamp: model.Timestamp{Time: now},
+			Latitude:  rng.Float64()*160 - 80,
+			Longitude: rng.Float64()*360 - 180,
+			Altitude:  10000,
+		}
+	}
+	return points
+}
+
+func BenchmarkGeofenceIndex_10k(b *testing.B) {
+	index := newGeofenceIndex(benchGeofences(benchGeofenceCount), defaultGeofenceCellSize)
+	service := &geofenceService{index: index}
+	points := benchPoints(1024)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		service.CheckGeofences(points[i%len(points)])
+	}
+}
+
+func BenchmarkGeofenceLinearScan_10k(b *testing.B) {
+	geofences := benchGeofences(benchGeofenceCount)
+	points := benchPoints(1024)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := points[i%len(points)]
+		for _, geofence := range geofences {
+			_ = geofence.IsActiveAt(p.Timestamp.Time) && geofence.ContainsPosition(p.Latitude, p.Longitude, p.Altitude)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+)
+
+// indexProbes returns points to look up around a geofence: a grid of up to 200 by 200 points over
+// its bounding box and a degree beyond, and a coarse grid over the globe. Both include cell
+// boundaries, the poles and the antimeridian.
+func indexProbes(geofence *model.Geofence) [][2]float64 {
+	var points [][2]float64
+	for lat := -90.0; lat <= 90; lat += 0.5 {
+		for lon := -180.0; lon <= 180; lon += 0.5 {
+			points = append(points, [2]float64{lat, lon})
+		}
+	}
+
+	minLat, maxLat, minLon, maxLon := geofence.Bounds()
+	if minLon > maxLon {
+		maxLon += 360
+	}
+	latStep := math.Max(0.05, (maxLat-minLat+2)/200)
+	lonStep := math.Max(0.05, (maxLon-minLon+2)/200)
+	for lat := math.Max(-90, minLat-1); lat <= math.Min(90, maxLat+1); lat += latStep {
+		for lon := minLon - 1; lon <= maxLon+1; lon += lonStep {
+			points = append(points, [2]float64{lat, model.NormalizeLongitude(lon)})
+		}
+	}
+	return points
+}
+
+func TestGeofenceIndexCandidatesCoverContainingGeofences(t *testing.T) {
+	tests := []struct {
+		name      string
+		geofences []*model.Geofence
+		wantLarge int // Geofences kept in the list checked for every point
+	}{
+		{"rectangle inside a cell", []*model.Geofence{rectangleGeofence(1, 41.2, 41.8, 29.1, 29.7)}, 0},
+		{"rectangle on cell boundaries", []*model.Geofence{rectangleGeofence(1, 40, 42, 28, 30)}, 0},
+		{"rectangle across the antimeridian", []*model.Geofence{rectangleGeofence(1, -1, 1, 179.5, -179.5)}, 0},
+		{"rectangle up to the antimeridian", []*model.Geofence{rectangleGeofence(1, 10, 11, 179, 180)}, 0},
+		{"rectangle from the antimeridian", []*model.Geofence{rectangleGeofence(1, 10, 11, -180, -179)}, 0},
+		{"polar rectangle", []*model.Geofence{rectangleGeofence(1, 89, 90, -180, 180)}, 0},
+		{"circle in a cell", []*model.Geofence{circleGeofence(1, 41.5, 29.5, 20000)}, 0},
+		{"circle on a cell corner", []*model.Geofence{circleGeofence(1, 41, 29, 50000)}, 0},
+		{"circle across the antimeridian", []*model.Geofence{circleGeofence(1, 0, 179.9, 50000)}, 0},
+		{"circle over the north pole", []*model.Geofence{circleGeofence(1, 89.5, 0, 200000)}, 0},
+		{"circle over the south pole", []*model.Geofence{circleGeofence(1, -89.5, 90, 120000)}, 0},
+		{"polygon", []*model.Geofence{polygonGeofence(1, "POLYGON((29 41, 30.5 41, 30 42.5, 29 41))")}, 0},
+		{"large rectangle", []*model.Geofence{rectangleGeofence(1, -80, 80, -180, 180)}, 1},
+		{"large circle", []*model.Geofence{circleGeofence(1, 0, 0, 5000000)}, 1},
+		{
+			name: "mixed",
+			geofences: []*model.Geofence{
+				rectangleGeofence(1, 40, 42, 28, 30),
+				circleGeofence(2, 41, 29, 50000),
+				rectangleGeofence(3, -1, 1, 179.5, -179.5),
+				circleGeofence(4, 89.5, 0, 200000),
+				rectangleGeofence(5, -80, 80, -180, 180),
+			},
+			wantLarge: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := newGeofenceIndex(tt.geofences, defaultGeofenceCellSize)
+			if len(idx.large) != tt.wantLarge {
+				t.Errorf("large geofences = %d, want %d", len(idx.large), tt.wantLarge)
+			}
+
+			for _, geofence := range tt.geofences {
+				matched := 0
+				for _, point := range indexProbes(geofence) {
+					lat, lon := point[0], point[1]
+					if !geofence.ContainsPoint(lat, lon) {
+						continue
+					}
+					matched++
+
+					found := false
+					for _, candidate := range idx.candidates(lat, lon) {
+						if candidate == geofence {
+							found = true
+							break
+						}
+					}
+					if !found {
+						t.Fatalf("candidates(%v, %v) misses geofence %d, which contains the point", lat, lon, geofence.ID)
+					}
+				}
+				if matched == 0 {
+					t.Fatalf("no probe point is inside geofence %d", geofence.ID)
+				}
+			}
+		})
+	}
+}
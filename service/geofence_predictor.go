@@ -0,0 +1,132 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+)
+
+const (
+	// predictionStep is the spacing of samples along the projected track. Each step is tested against
+	// geofence shapes as a segment, so geofences narrower than a step are still found.
+	predictionStep = 5 * time.Second
+	// predictionRefinements is the number of bisection steps used to narrow down a vertical entry time
+	predictionRefinements = 8
+)
+
+// PredictApproaches projects the aircraft forward for lookAhead along its current heading, ground
+// speed (knots) and climb rate (altitude units per minute) and returns the geofences the track enters,
+// ordered by time to entry. Geofences the aircraft is already inside are not reported.
+func (s *geofenceService) PredictApproaches(telemetry *model.TelemetryDTO, lookAhead time.Duration) []model.GeofenceApproach {
+	if lookAhead <= 0 || (telemetry.GroundSpeed <= 0 && telemetry.ClimbRate == 0) {
+		return nil
+	}
+
+	s.mu.RLock()
+	index := s.index
+	s.mu.RUnlock()
+
+	start := telemetry.Timestamp.Time
+	speed := telemetry.GroundSpeed * model.MetersPerNauticalMile / 3600 // m/s
+	climbRate := telemetry.ClimbRate / 60                               // altitude units per second
+
+	position := func(elapsed float64) (float64, float64, float64) {
+		lat, lon := telemetry.Latitude, telemetry.Longitude
+		if speed > 0 {
+			lat, lon = model.Destination(lat, lon, telemetry.Heading, speed*elapsed)
+		}
+		return lat, lon, telemetry.Altitude + climbRate*elapsed
+	}
+	inside := func(geofence *model.Geofence, elapsed float64) bool {
+		lat, lon, alt := position(elapsed)
+		at := start.Add(time.Duration(elapsed * float64(time.Second)))
+		return geofence.IsActiveAt(at) && geofence.ContainsPosition(lat, lon, alt)
+	}
+
+	// Skip geofences the aircraft is already inside; they are reported as violations
+	skip := make(map[uint]struct{})
+	for _, geofence := range index.candidates(telemetry.Latitude, telemetry.Longitude) {
+		if inside(geofence, 0) {
+			skip[geofence.ID] = struct{}{}
+		}
+	}
+
+	// entry returns when the track first enters the geofence between two sample times
+	entry := func(geofence *model.Geofence, from, to float64) (float64, bool) {
+		fromLat, fromLon, _ := position(from)
+		toLat, toLon, _ := position(to)
+
+		// Crossing into the area, however narrow it is
+		if t, ok := geofence.SegmentEntry(fromLat, fromLon, toLat, toLon); ok {
+			if at := from + t*(to-from); inside(geofence, at) {
+				return at, true
+			}
+		}
+
+		// Climbing or descending into the altitude band, or the geofence becoming active, over the area.
+		// Bisect between the samples for a tighter entry estimate.
+		if !inside(geofence, to) {
+			return 0, false
+		}
+		low, high := from, to
+		for j := 0; j < predictionRefinements; j++ {
+			mid := (low + high) / 2
+			if inside(geofence, mid) {
+				high = mid
+			} else {
+				low = mid
+			}
+		}
+		return high, true
+	}
+
+	var approaches []model.GeofenceApproach
+	horizon := lookAhead.Seconds()
+	step := predictionStep.Seconds()
+	previous := 0.0
+	previousLat, previousLon := telemetry.Latitude, telemetry.Longitude
+	for i := 1; previous < horizon; i++ {
+		elapsed := math.Min(float64(i)*step, horizon)
+		lat, lon, _ := position(elapsed)
+
+		// The step may cross from one index cell into another
+		candidates := index.candidates(previousLat, previousLon)
+		if index.cellOf(lat, lon) != index.cellOf(previousLat, previousLon) {
+			candidates = append(append([]*model.Geofence(nil), candidates...), index.candidates(lat, lon)...)
+		}
+
+		for _, geofence := range candidates {
+			if _, ok := skip[geofence.ID]; ok {
+				continue
+			}
+			at, ok := entry(geofence, previous, elapsed)
+			if !ok {
+				continue
+			}
+			skip[geofence.ID] = struct{}{}
+
+			entryLat, entryLon, entryAlt := position(at)
+			approaches = append(approaches, model.GeofenceApproach{
+				GeofenceID:         geofence.ID,
+				GeofenceName:       geofence.Name,
+				TimeToEntrySeconds: at,
+				EntryLatitude:      entryLat,
+				EntryLongitude:     entryLon,
+				EntryAltitude:      entryAlt,
+			})
+		}
+
+		previous, previousLat, previousLon = elapsed, lat, lon
+	}
+
+	sort.Slice(approaches, func(i, j int) bool {
+		if approaches[i].TimeToEntrySeconds != approaches[j].TimeToEntrySeconds {
+			return approaches[i].TimeToEntrySeconds < approaches[j].TimeToEntrySeconds
+		}
+		return approaches[i].GeofenceID < approaches[j].GeofenceID
+	})
+
+	return approaches
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+)
+
+// knots250 is a ground speed of 250 m/s in knots
+const knots250 = 250 * 3600 / model.MetersPerNauticalMile
+
+func circleGeofence(id uint, lat, lon, radius float64) *model.Geofence {
+	geofence := &model.Geofence{
+		Name: "circle", Shape: model.GeofenceShapeCircle, IsActive: true,
+		CenterLatitude: &lat, CenterLongitude: &lon, Radius: &radius,
+	}
+	geofence.ID = id
+	_ = geofence.BeforeSave(nil)
+	return geofence
+}
+
+func rectangleGeofence(id uint, minLat, maxLat, minLon, maxLon float64) *model.Geofence {
+	geofence := &model.Geofence{
+		Name: "rectangle", Shape: model.GeofenceShapeRectangle, IsActive: true,
+		MinLatitude: minLat, MaxLatitude: maxLat, MinLongitude: minLon, MaxLongitude: maxLon,
+	}
+	geofence.ID = id
+	_ = geofence.BeforeSave(nil)
+	return geofence
+}
+
+func polygonGeofence(id uint, wkt string) *model.Geofence {
+	geofence := &model.Geofence{Name: "polygon", Shape: model.GeofenceShapePolygon, IsActive: true, Geometry: wkt}
+	geofence.ID = id
+	_ = geofence.BeforeSave(nil)
+	return geofence
+}
+
+func TestGeofenceServicePredictApproaches(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	// Metres per degree of longitude at the equator
+	degree := model.EarthRadiusMeters * math.Pi / 180
+
+	floor := 3000.0
+	climbBand := rectangleGeofence(1, -1, 1, -1, 1)
+	climbBand.MinAltitude = &floor
+
+	tests := []struct {
+		name      string
+		geofence  *model.Geofence
+		lon       float64 // Start longitude on the equator
+		heading   float64
+		speed     float64 // Knots
+		climbRate float64 // Per minute
+		altitude  float64
+		wantEntry float64 // Seconds, negative when no approach is expected
+	}{
+		{
+			name:      "head-on circle",
+			geofence:  circleGeofence(1, 0, 0.2, 1000),
+			heading:   90,
+			speed:     knots250,
+			wantEntry: (0.2*degree - 1000) / 250,
+		},
+		{
+			name:      "head-on rectangle",
+			geofence:  rectangleGeofence(1, -0.1, 0.1, 0.2, 0.3),
+			heading:   90,
+			speed:     knots250,
+			wantEntry: 0.2 * degree / 250,
+		},
+		{
+			name:      "tangent pass outside",
+			geofence:  circleGeofence(1, 1200/degree, 0.2, 1000),
+			heading:   90,
+			speed:     knots250,
+			wantEntry: -1,
+		},
+		{
+			name:      "grazing pass inside",
+			geofence:  circleGeofence(1, 800/degree, 0.2, 1000),
+			heading:   90,
+			speed:     knots250,
+			wantEntry: (0.2*degree - 600) / 250,
+		},
+		{
+			name:      "thin circle between samples",
+			geofence:  circleGeofence(1, 0, 0.1, 100),
+			heading:   90,
+			speed:     knots250,
+			wantEntry: (0.1*degree - 100) / 250,
+		},
+		{
+			name:      "thin rectangle between samples",
+			geofence:  rectangleGeofence(1, -1, 1, 0.1, 0.101),
+			heading:   90,
+			speed:     knots250,
+			wantEntry: 0.1 * degree / 250,
+		},
+		{
+			name:      "thin diagonal polygon between samples",
+			geofence:  polygonGeofence(1, "POLYGON((0.1 -1, 0.101 -1, 0.111 1, 0.11 1, 0.1 -1))"),
+			heading:   90,
+			speed:     knots250,
+			wantEntry: 0.105 * degree / 250,
+		},
+		{
+			name:      "thin fence across the antimeridian",
+			geofence:  rectangleGeofence(1, -1, 1, -179.999, -179.998),
+			lon:       179.95,
+			heading:   90,
+			speed:     knots250,
+			wantEntry: 0.051 * degree / 250,
+		},
+		{
+			name:      "heading away",
+			geofence:  circleGeofence(1, 0, 0.2, 1000),
+			heading:   270,
+			speed:     knots250,
+			wantEntry: -1,
+		},
+		{
+			name:      "beyond the look-ahead",
+			geofence:  circleGeofence(1, 0, 1, 1000),
+			heading:   90,
+			speed:     knots250,
+			wantEntry: -1,
+		},
+		{
+			name:      "climbing into the altitude band",
+			geofence:  climbBand,
+			speed:     0,
+			climbRate: 6000,
+			altitude:  1000,
+			wantEntry: 20,
+		},
+		{
+			name:      "already inside",
+			geofence:  circleGeofence(1, 0, 0, 1000),
+			heading:   90,
+			speed:     knots250,
+			wantEntry: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &geofenceService{index: newGeofenceIndex([]*model.Geofence{tt.geofence}, defaultGeofenceCellSize)}
+
+			telemetry := &model.TelemetryDTO{
+				Longitude: tt.lon, Heading: tt.heading, GroundSpeed: tt.speed,
+				ClimbRate: tt.climbRate, Altitude: tt.altitude,
+			}
+			telemetry.Timestamp.Time = start
+
+			approaches := s.PredictApproaches(telemetry, 2*time.Minute)
+			if tt.wantEntry < 0 {
+				if len(approaches) != 0 {
+					t.Errorf("approaches = %+v, want none", approaches)
+				}
+				return
+			}
+			if len(approaches) != 1 {
+				t.Fatalf("approaches = %+v, want one", approaches)
+			}
+			if got := approaches[0].TimeToEntrySeconds; math.Abs(got-tt.wantEntry) > 0.5 {
+				t.Errorf("time to entry = %.2fs, want %.2fs", got, tt.wantEntry)
+			}
+		})
+	}
+}
@@ -1,41 +1,151 @@
 package service
 
 import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
 	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/logging"
 	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/repository"
+	"go.uber.org/zap"
 )
 
+const defaultGeofenceRefreshInterval = 30 * time.Second
+
 // GeofenceService handles geofence checking operations
 type GeofenceService interface {
-	CheckGeofences(lat, lon float64) (bool, []*model.Geofence) // returns (isViolation, violatingGeofences)
+	CheckGeofences(telemetry *model.TelemetryDTO) (bool, []*model.Geofence) // returns (isViolation, violatingGeofences)
+	PredictApproaches(telemetry *model.TelemetryDTO, lookAhead time.Duration) []model.GeofenceApproach
+	Refresh() error
+	WatchChanges(ctx context.Context)
 }
 
 type geofenceService struct {
-	geofenceRepo repository.GeofenceRepository
+	geofenceRepo    repository.GeofenceRepository
+	refreshInterval time.Duration
+	mu              sync.RWMutex
+	index           *geofenceIndex
+	lastChange      time.Time
+	total           int64
 }
 
-// NewGeofenceService creates a new geofence service
-func NewGeofenceService(geofenceRepo repository.GeofenceRepository) GeofenceService {
+// NewGeofenceService creates a new geofence service.
+// Geofences are served from an in-memory index; call Refresh to load it and WatchChanges to keep it current.
+func NewGeofenceService(geofenceRepo repository.GeofenceRepository, refreshInterval time.Duration) GeofenceService {
+	if refreshInterval <= 0 {
+		refreshInterval = defaultGeofenceRefreshInterval
+	}
+
 	return &geofenceService{
-		geofenceRepo: geofenceRepo,
+		geofenceRepo:    geofenceRepo,
+		refreshInterval: refreshInterval,
+		index:           newGeofenceIndex(nil, defaultGeofenceCellSize),
 	}
 }
 
-// CheckGeofences checks if a point (lat, lon) is inside any active geofence
+// CheckGeofences checks if the telemetry position (lat, lon, altitude) is inside any geofence active at the telemetry time
 // Returns (isViolation, list of violating geofences)
-func (s *geofenceService) CheckGeofences(lat, lon float64) (bool, []*model.Geofence) {
+func (s *geofenceService) CheckGeofences(telemetry *model.TelemetryDTO) (bool, []*model.Geofence) {
+	s.mu.RLock()
+	index := s.index
+	s.mu.RUnlock()
+
+	var violatingGeofences []*model.Geofence
+	for _, geofence := range index.candidates(telemetry.Latitude, telemetry.Longitude) {
+		// Activity is evaluated at the telemetry time so replays and backfills get the correct result
+		if geofence.IsActiveAt(telemetry.Timestamp.Time) &&
+			geofence.ContainsPosition(telemetry.Latitude, telemetry.Longitude, telemetry.Altitude) {
+			violatingGeofences = append(violatingGeofences, geofence)
+		}
+	}
+
+	// Candidate order depends on the index layout; report geofences by ID
+	sort.Slice(violatingGeofences, func(i, j int) bool {
+		return violatingGeofences[i].ID < violatingGeofences[j].ID
+	})
+
+	return len(violatingGeofences) > 0, violatingGeofences
+}
+
+// Refresh reloads active geofences from the database and rebuilds the index
+func (s *geofenceService) Refresh() error {
+	lastChange, total, err := s.geofenceRepo.GetLastChange()
+	if err != nil {
+		return fmt.Errorf("failed to get geofence change marker: %w", err)
+	}
+
 	geofences, err := s.geofenceRepo.GetAllActive()
 	if err != nil {
-		// Log error but return no violation
-		return false, nil
+		return fmt.Errorf("failed to load active geofences: %w", err)
 	}
 
-	var violatingGeofences []*model.Geofence
 	for _, geofence := range geofences {
-		if geofence.ContainsPoint(lat, lon) {
-			violatingGeofences = append(violatingGeofences, geofence)
+		if err := geofence.GeometryError(); err != nil {
+			logging.Warn("Geofence has invalid geometry and will never match",
+				zap.Uint("geofence_id", geofence.ID),
+				zap.String("name", geofence.Name),
+				zap.Error(err),
+			)
+		}
+		if err := geofence.TimeZoneError(); err != nil && len(geofence.Schedule) > 0 {
+			logging.Warn("Geofence has an invalid time zone and its schedule will never be active",
+				zap.Uint("geofence_id", geofence.ID),
+				zap.String("name", geofence.Name),
+				zap.String("time_zone", geofence.TimeZone),
+				zap.Error(err),
+			)
 		}
 	}
 
-	return len(violatingGeofences) > 0, violatingGeofences
+	index := newGeofenceIndex(geofences, defaultGeofenceCellSize)
+
+	s.mu.Lock()
+	s.index = index
+	s.lastChange = lastChange
+	s.total = total
+	s.mu.Unlock()
+
+	logging.Info("Geofence index loaded",
+		zap.Int("geofences", index.size),
+		zap.Int("cells", len(index.cells)),
+		zap.Int("large", len(index.large)),
+	)
+
+	return nil
+}
+
+// WatchChanges polls for geofence changes and refreshes the index when any are found
+func (s *geofenceService) WatchChanges(ctx context.Context) {
+	ticker := time.NewTicker(s.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !s.hasChanged() {
+				continue
+			}
+			if err := s.Refresh(); err != nil {
+				logging.Error("Failed to refresh geofence index", zap.Error(err))
+			}
+		}
+	}
+}
+
+// hasChanged reports whether geofences were modified since the last refresh
+func (s *geofenceService) hasChanged() bool {
+	lastChange, total, err := s.geofenceRepo.GetLastChange()
+	if err != nil {
+		logging.Error("Failed to check geofence changes", zap.Error(err))
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !lastChange.Equal(s.lastChange) || total != s.total
 }
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/logging"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/publisher"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/repository"
+	"go.uber.org/zap"
+)
+
+// GeofenceTracker turns per-point geofence matches into entry, exit and dwell events
+type GeofenceTracker interface {
+	Load() error
+	WatchStale(ctx context.Context)
+	Track(aircraftID uint, telemetry *model.TelemetryDTO, insideGeofences []*model.Geofence) ([]*model.GeofenceEvent, error)
+}
+
+const (
+	// incursionStaleAfter is how long an aircraft may stop reporting before its incursions are closed
+	incursionStaleAfter = 30 * time.Minute
+	// staleSweepInterval is how often incursions of aircraft that stopped reporting are looked for
+	staleSweepInterval = time.Minute
+)
+
+// incursion is an aircraft's ongoing presence inside a geofence
+type incursion struct {
+	geofenceName string
+	enteredAt    time.Time
+	dwellAlerted bool
+}
+
+// aircraftIncursions holds an aircraft's ongoing incursions and its last reported position.
+// It is kept while the aircraft reports, even outside every geofence, so late samples can be recognised.
+type aircraftIncursions struct {
+	mu            sync.Mutex
+	removed       bool      // Dropped from the tracker by closeStale; a new entry must be used
+	lastReported  time.Time // Wall-clock time of the last telemetry, so delayed telemetry doesn't look stale
+	lastSeen      time.Time // Telemetry time of the last position
+	lastLatitude  float64
+	lastLongitude float64
+	lastAltitude  float64
+	geofences     map[uint]*incursion // geofence ID -> incursion
+}
+
+type geofenceTracker struct {
+	eventRepo       repository.GeofenceEventRepository
+	feedPublisher   publisher.FeedPublisher
+	defaultMaxDwell time.Duration
+	mu              sync.Mutex                   // Guards the incursions map; each aircraft has its own lock
+	incursions      map[uint]*aircraftIncursions // aircraft ID -> incursions
+}
+
+// NewGeofenceTracker creates a new geofence tracker.
+// defaultMaxDwell applies to geofences without their own dwell limit; zero disables dwell events for them.
+// Exit events of aircraft that stopped reporting are published to feedPublisher by WatchStale.
+func NewGeofenceTracker(
+	eventRepo repository.GeofenceEventRepository,
+	feedPublisher publisher.FeedPublisher,
+	defaultMaxDwell time.Duration,
+) GeofenceTracker {
+	return &geofenceTracker{
+		eventRepo:       eventRepo,
+		feedPublisher:   feedPublisher,
+		defaultMaxDwell: defaultMaxDwell,
+		incursions:      make(map[uint]*aircraftIncursions),
+	}
+}
+
+// Load restores ongoing incursions from persisted events so a restart doesn't re-emit ENTERED events
+func (t *geofenceTracker) Load() error {
+	events, err := t.eventRepo.GetOpen()
+	if err != nil {
+		return fmt.Errorf("failed to load open geofence incursions: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.incursions = make(map[uint]*aircraftIncursions)
+	for _, event := range events {
+		aircraft := t.incursions[event.AircraftID]
+		if aircraft == nil {
+			aircraft = &aircraftIncursions{geofences: make(map[uint]*incursion)}
+			t.incursions[event.AircraftID] = aircraft
+		}
+		if event.OccurredAt.After(aircraft.lastSeen) {
+			aircraft.lastReported = event.OccurredAt
+			aircraft.lastSeen = event.OccurredAt
+			aircraft.lastLatitude, aircraft.lastLongitude, aircraft.lastAltitude = event.Latitude, event.Longitude, event.Altitude
+		}
+		aircraft.geofences[event.GeofenceID] = &incursion{
+			geofenceName: event.GeofenceName,
+			enteredAt:    event.EnteredAt,
+			dwellAlerted: event.EventType == model.GeofenceEventDwellExceeded,
+		}
+	}
+
+	logging.Info("Geofence incursions loaded", zap.Int("open", len(events)))
+
+	return nil
+}
+
+// Track compares the geofences an aircraft is currently inside with its previous state and
+// returns (and persists) the resulting events: entries and dwell alerts in the order of insideGeofences,
+// then exits by geofence ID. Samples older than the aircraft's latest one produce no events.
+// If the events can't be saved the state is left unchanged and an error is returned, so the same
+// telemetry produces them again when it is retried.
+func (t *geofenceTracker) Track(aircraftID uint, telemetry *model.TelemetryDTO, insideGeofences []*model.Geofence) ([]*model.GeofenceEvent, error) {
+	now := telemetry.Timestamp.Time
+
+	// Only this aircraft is locked, so other aircraft are tracked while its events are saved
+	aircraft := t.aircraftFor(aircraftID)
+	defer aircraft.mu.Unlock()
+
+	aircraft.lastReported = time.Now()
+	// A late or redelivered sample would flap the aircraft out of and back into its geofences
+	if now.Before(aircraft.lastSeen) {
+		return nil, nil
+	}
+
+	// Changes are collected first and only applied once the events are saved
+	current := aircraft.geofences
+	entered := make(map[uint]*incursion)
+	var dwelled []*incursion
+	var events []*model.GeofenceEvent
+
+	inside := make(map[uint]struct{}, len(insideGeofences))
+	for _, geofence := range insideGeofences {
+		inside[geofence.ID] = struct{}{}
+
+		state, ok := current[geofence.ID]
+		if !ok {
+			entered[geofence.ID] = &incursion{geofenceName: geofence.Name, enteredAt: now}
+			events = append(events, newGeofenceEvent(aircraftID, geofence.ID, geofence.Name, model.GeofenceEventEntered, now, now,
+				telemetry.Latitude, telemetry.Longitude, telemetry.Altitude))
+			continue
+		}
+
+		maxDwell := t.defaultMaxDwell
+		if geofence.MaxDwellSeconds != nil {
+			maxDwell = time.Duration(*geofence.MaxDwellSeconds) * time.Second
+		}
+		if maxDwell > 0 && !state.dwellAlerted && now.Sub(state.enteredAt) >= maxDwell {
+			dwelled = append(dwelled, state)
+			events = append(events, newGeofenceEvent(aircraftID, geofence.ID, geofence.Name, model.GeofenceEventDwellExceeded, state.enteredAt, now,
+				telemetry.Latitude, telemetry.Longitude, telemetry.Altitude))
+		}
+	}
+
+	var exited []uint
+	for geofenceID := range current {
+		if _, ok := inside[geofenceID]; !ok {
+			exited = append(exited, geofenceID)
+		}
+	}
+	sort.Slice(exited, func(i, j int) bool { return exited[i] < exited[j] })
+	for _, geofenceID := range exited {
+		state := current[geofenceID]
+		events = append(events, newGeofenceEvent(aircraftID, geofenceID, state.geofenceName, model.GeofenceEventExited, state.enteredAt, now,
+			telemetry.Latitude, telemetry.Longitude, telemetry.Altitude))
+	}
+
+	if err := t.eventRepo.CreateBatch(events); err != nil {
+		return nil, fmt.Errorf("failed to save geofence events: %w", err)
+	}
+
+	aircraft.lastSeen = now
+	aircraft.lastLatitude, aircraft.lastLongitude, aircraft.lastAltitude = telemetry.Latitude, telemetry.Longitude, telemetry.Altitude
+	for geofenceID, state := range entered {
+		aircraft.geofences[geofenceID] = state
+	}
+	for _, state := range dwelled {
+		state.dwellAlerted = true
+	}
+	for _, geofenceID := range exited {
+		delete(aircraft.geofences, geofenceID)
+	}
+
+	return events, nil
+}
+
+// aircraftFor returns the aircraft's incursions, locked, creating them on its first sample
+func (t *geofenceTracker) aircraftFor(aircraftID uint) *aircraftIncursions {
+	for {
+		t.mu.Lock()
+		aircraft := t.incursions[aircraftID]
+		if aircraft == nil {
+			aircraft = &aircraftIncursions{geofences: make(map[uint]*incursion)}
+			t.incursions[aircraftID] = aircraft
+		}
+		t.mu.Unlock()
+
+		aircraft.mu.Lock()
+		if !aircraft.removed {
+			return aircraft
+		}
+		aircraft.mu.Unlock()
+		t.forget(aircraftID, aircraft)
+	}
+}
+
+// forget drops an aircraft's incursions from the tracker unless they were already replaced
+func (t *geofenceTracker) forget(aircraftID uint, aircraft *aircraftIncursions) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.incursions[aircraftID] == aircraft {
+		delete(t.incursions, aircraftID)
+	}
+}
+
+// WatchStale periodically closes the incursions of aircraft that stopped reporting and publishes
+// their exit events, until ctx is cancelled
+func (t *geofenceTracker) WatchStale(ctx context.Context) {
+	ticker := time.NewTicker(staleSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Events of the aircraft that were closed are published even if others failed
+			events, err := t.closeStale(time.Now())
+			if err != nil {
+				logging.Error("Failed to close stale geofence incursions", zap.Error(err))
+			}
+			for _, event := range events {
+				if err := t.feedPublisher.PublishGeofenceEvent(ctx, event); err != nil {
+					logging.Error("Failed to publish geofence event",
+						zap.Error(err),
+						zap.Uint("aircraft_id", event.AircraftID),
+						zap.Uint("geofence_id", event.GeofenceID),
+						zap.String("event_type", string(event.EventType)),
+					)
+				}
+			}
+		}
+	}
+}
+
+// closeStale drops the incursions of aircraft that have not reported for incursionStaleAfter and
+// returns (and persists) exit events for them at the aircraft's last position, by aircraft and geofence ID.
+// The aircraft whose events can't be saved keep their incursions and are closed by a later sweep;
+// the events of the others are returned along with the error.
+func (t *geofenceTracker) closeStale(now time.Time) ([]*model.GeofenceEvent, error) {
+	t.mu.Lock()
+	aircraftIDs := make([]uint, 0, len(t.incursions))
+	for aircraftID := range t.incursions {
+		aircraftIDs = append(aircraftIDs, aircraftID)
+	}
+	t.mu.Unlock()
+	sort.Slice(aircraftIDs, func(i, j int) bool { return aircraftIDs[i] < aircraftIDs[j] })
+
+	var events []*model.GeofenceEvent
+	var errs []error
+	for _, aircraftID := range aircraftIDs {
+		closed, err := t.closeStaleAircraft(aircraftID, now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		events = append(events, closed...)
+	}
+
+	return events, errors.Join(errs...)
+}
+
+// closeStaleAircraft closes the incursions of one aircraft if it has not reported for incursionStaleAfter
+func (t *geofenceTracker) closeStaleAircraft(aircraftID uint, now time.Time) ([]*model.GeofenceEvent, error) {
+	t.mu.Lock()
+	aircraft := t.incursions[aircraftID]
+	t.mu.Unlock()
+	if aircraft == nil {
+		return nil, nil
+	}
+
+	aircraft.mu.Lock()
+	defer aircraft.mu.Unlock()
+	if aircraft.removed || now.Sub(aircraft.lastReported) <= incursionStaleAfter {
+		return nil, nil
+	}
+
+	geofenceIDs := make([]uint, 0, len(aircraft.geofences))
+	for geofenceID := range aircraft.geofences {
+		geofenceIDs = append(geofenceIDs, geofenceID)
+	}
+	sort.Slice(geofenceIDs, func(i, j int) bool { return geofenceIDs[i] < geofenceIDs[j] })
+
+	events := make([]*model.GeofenceEvent, 0, len(geofenceIDs))
+	for _, geofenceID := range geofenceIDs {
+		state := aircraft.geofences[geofenceID]
+		events = append(events, newGeofenceEvent(aircraftID, geofenceID, state.geofenceName, model.GeofenceEventExited,
+			state.enteredAt, aircraft.lastSeen, aircraft.lastLatitude, aircraft.lastLongitude, aircraft.lastAltitude))
+	}
+
+	if err := t.eventRepo.CreateBatch(events); err != nil {
+		return nil, fmt.Errorf("failed to save geofence exit events of aircraft %d: %w", aircraftID, err)
+	}
+
+	aircraft.removed = true
+	t.forget(aircraftID, aircraft)
+
+	if len(events) > 0 {
+		logging.Info("Closed geofence incursions of aircraft that stopped reporting",
+			zap.Uint("aircraft_id", aircraftID),
+			zap.Time("last_seen", aircraft.lastSeen),
+			zap.Int("incursions", len(events)),
+		)
+	}
+
+	return events, nil
+}
+
+// newGeofenceEvent builds a geofence event that occurred at the given time and position
+func newGeofenceEvent(
+	aircraftID, geofenceID uint,
+	geofenceName string,
+	eventType model.GeofenceEventType,
+	enteredAt, occurredAt time.Time,
+	latitude, longitude, altitude float64,
+) *model.GeofenceEvent {
+	return &model.GeofenceEvent{
+		AircraftID:      aircraftID,
+		GeofenceID:      geofenceID,
+		GeofenceName:    geofenceName,
+		EventType:       eventType,
+		EnteredAt:       enteredAt,
+		OccurredAt:      occurredAt,
+		DurationSeconds: occurredAt.Sub(enteredAt).Seconds(),
+		Latitude:        latitude,
+		Longitude:       longitude,
+		Altitude:        altitude,
+	}
+}
@@ -0,0 +1,355 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+)
+
+// trackedEvent is the part of a geofence event the tracker tests compare
+type trackedEvent struct {
+	geofenceID uint
+	eventType  model.GeofenceEventType
+}
+
+func trackedEvents(events []*model.GeofenceEvent) []trackedEvent {
+	got := make([]trackedEvent, len(events))
+	for i, event := range events {
+		got[i] = trackedEvent{event.GeofenceID, event.EventType}
+	}
+	return got
+}
+
+func equalTrackedEvents(a, b []trackedEvent) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// trackerGeofences returns geofences by ID; geofence 1 has a one minute dwell limit
+func trackerGeofences(ids ...uint) []*model.Geofence {
+	maxDwell := 60
+	geofences := make([]*model.Geofence, len(ids))
+	for i, id := range ids {
+		geofences[i] = &model.Geofence{Name: "zone", IsActive: true}
+		geofences[i].ID = id
+		if id == 1 {
+			geofences[i].MaxDwellSeconds = &maxDwell
+		}
+	}
+	return geofences
+}
+
+func positionAt(at time.Time) *model.TelemetryDTO {
+	telemetry := &model.TelemetryDTO{Latitude: 41, Longitude: 29, Altitude: 1000}
+	telemetry.Timestamp.Time = at
+	return telemetry
+}
+
+func TestGeofenceTrackerTransitions(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	type step struct {
+		at     time.Duration // Relative to start
+		inside []uint
+		want   []trackedEvent
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "enter and exit",
+			steps: []step{
+				{0, []uint{2}, []trackedEvent{{2, model.GeofenceEventEntered}}},
+				{10 * time.Second, []uint{2}, nil},
+				{20 * time.Second, nil, []trackedEvent{{2, model.GeofenceEventExited}}},
+			},
+		},
+		{
+			name: "dwell exceeded once",
+			steps: []step{
+				{0, []uint{1}, []trackedEvent{{1, model.GeofenceEventEntered}}},
+				{59 * time.Second, []uint{1}, nil},
+				{time.Minute, []uint{1}, []trackedEvent{{1, model.GeofenceEventDwellExceeded}}},
+				{2 * time.Minute, []uint{1}, nil},
+			},
+		},
+		{
+			name: "no dwell limit",
+			steps: []step{
+				{0, []uint{2}, []trackedEvent{{2, model.GeofenceEventEntered}}},
+				{time.Hour, []uint{2}, nil},
+			},
+		},
+		{
+			name: "entries in match order, exits by geofence ID",
+			steps: []step{
+				{0, []uint{3, 2}, []trackedEvent{{3, model.GeofenceEventEntered}, {2, model.GeofenceEventEntered}}},
+				{10 * time.Second, nil, []trackedEvent{{2, model.GeofenceEventExited}, {3, model.GeofenceEventExited}}},
+			},
+		},
+		{
+			name: "moving between geofences",
+			steps: []step{
+				{0, []uint{2}, []trackedEvent{{2, model.GeofenceEventEntered}}},
+				{10 * time.Second, []uint{3}, []trackedEvent{{3, model.GeofenceEventEntered}, {2, model.GeofenceEventExited}}},
+			},
+		},
+		{
+			name: "late sample after exit",
+			steps: []step{
+				{0, []uint{2}, []trackedEvent{{2, model.GeofenceEventEntered}}},
+				{20 * time.Second, nil, []trackedEvent{{2, model.GeofenceEventExited}}},
+				{10 * time.Second, []uint{2}, nil},
+				{30 * time.Second, nil, nil},
+			},
+		},
+		{
+			name: "late sample while inside",
+			steps: []step{
+				{10 * time.Second, []uint{2}, []trackedEvent{{2, model.GeofenceEventEntered}}},
+				{20 * time.Second, []uint{2}, nil},
+				{0, nil, nil},
+				{30 * time.Second, nil, []trackedEvent{{2, model.GeofenceEventExited}}},
+			},
+		},
+		{
+			name: "redelivered sample",
+			steps: []step{
+				{0, []uint{2}, []trackedEvent{{2, model.GeofenceEventEntered}}},
+				{10 * time.Second, nil, []trackedEvent{{2, model.GeofenceEventExited}}},
+				{10 * time.Second, nil, nil},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryGeofenceEventRepo{}
+			tracker := NewGeofenceTracker(repo, nil, 0)
+
+			saved := 0
+			for i, s := range tt.steps {
+				events, err := tracker.Track(7, positionAt(start.Add(s.at)), trackerGeofences(s.inside...))
+				if err != nil {
+					t.Fatalf("step %d: Track() error = %v", i, err)
+				}
+				if got := trackedEvents(events); !equalTrackedEvents(got, s.want) {
+					t.Errorf("step %d: events = %v, want %v", i, got, s.want)
+				}
+				for _, event := range events {
+					if event.DurationSeconds < 0 {
+						t.Errorf("step %d: %s event lasted %vs", i, event.EventType, event.DurationSeconds)
+					}
+				}
+				saved += len(events)
+			}
+			if len(repo.saved) != saved {
+				t.Errorf("saved events = %d, want %d", len(repo.saved), saved)
+			}
+		})
+	}
+}
+
+func TestGeofenceTrackerSaveFailure(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo := &memoryGeofenceEventRepo{}
+	tracker := NewGeofenceTracker(repo, nil, 0)
+
+	if _, err := tracker.Track(7, positionAt(start), trackerGeofences(2)); err != nil {
+		t.Fatalf("Track() error = %v", err)
+	}
+
+	// The exit can't be saved, so the incursion stays open and the retried sample exits again
+	repo.saveErr = errors.New("database unavailable")
+	events, err := tracker.Track(7, positionAt(start.Add(time.Second)), nil)
+	if err == nil || events != nil {
+		t.Fatalf("Track() = %v, %v; want an error and no events", events, err)
+	}
+
+	repo.saveErr = nil
+	events, err = tracker.Track(7, positionAt(start.Add(time.Second)), nil)
+	if err != nil {
+		t.Fatalf("retried Track() error = %v", err)
+	}
+	want := []trackedEvent{{2, model.GeofenceEventExited}}
+	if got := trackedEvents(events); !equalTrackedEvents(got, want) {
+		t.Errorf("retried events = %v, want %v", got, want)
+	}
+}
+
+// blockingGeofenceEventRepo holds CreateBatch for one aircraft until it is released
+type blockingGeofenceEventRepo struct {
+	memoryGeofenceEventRepo
+	aircraftID uint
+	saving     chan struct{} // Closed once the held batch is being saved
+	release    chan struct{}
+}
+
+func (r *blockingGeofenceEventRepo) CreateBatch(events []*model.GeofenceEvent) error {
+	if len(events) > 0 && events[0].AircraftID == r.aircraftID {
+		close(r.saving)
+		<-r.release
+	}
+	return r.memoryGeofenceEventRepo.CreateBatch(events)
+}
+
+func TestGeofenceTrackerSavesAircraftConcurrently(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo := &blockingGeofenceEventRepo{aircraftID: 7, saving: make(chan struct{}), release: make(chan struct{})}
+	tracker := NewGeofenceTracker(repo, nil, 0)
+
+	held := make(chan error)
+	go func() {
+		_, err := tracker.Track(7, positionAt(start), trackerGeofences(2))
+		held <- err
+	}()
+	<-repo.saving
+
+	// Another aircraft is tracked while aircraft 7's events are still being saved
+	tracked := make(chan error)
+	go func() {
+		_, err := tracker.Track(8, positionAt(start), trackerGeofences(2))
+		tracked <- err
+	}()
+	select {
+	case err := <-tracked:
+		if err != nil {
+			t.Errorf("Track(8) error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Track(8) blocked while aircraft 7's events were saved")
+	}
+
+	close(repo.release)
+	if err := <-held; err != nil {
+		t.Errorf("Track(7) error = %v", err)
+	}
+	if len(repo.saved) != 2 {
+		t.Errorf("saved events = %d, want 2", len(repo.saved))
+	}
+}
+
+func TestGeofenceTrackerLoad(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		open   []*model.GeofenceEvent
+		at     time.Duration // Sample after loading, relative to start
+		inside []uint
+		want   []trackedEvent
+	}{
+		{
+			name:   "open incursion is not entered again",
+			open:   []*model.GeofenceEvent{{AircraftID: 7, GeofenceID: 2, EventType: model.GeofenceEventEntered, EnteredAt: start, OccurredAt: start}},
+			at:     time.Second,
+			inside: []uint{2},
+			want:   nil,
+		},
+		{
+			name:   "open incursion exits",
+			open:   []*model.GeofenceEvent{{AircraftID: 7, GeofenceID: 2, EventType: model.GeofenceEventEntered, EnteredAt: start, OccurredAt: start}},
+			at:     time.Second,
+			inside: nil,
+			want:   []trackedEvent{{2, model.GeofenceEventExited}},
+		},
+		{
+			name:   "dwell measured from the loaded entry",
+			open:   []*model.GeofenceEvent{{AircraftID: 7, GeofenceID: 1, EventType: model.GeofenceEventEntered, EnteredAt: start, OccurredAt: start}},
+			at:     time.Minute,
+			inside: []uint{1},
+			want:   []trackedEvent{{1, model.GeofenceEventDwellExceeded}},
+		},
+		{
+			name: "dwell already alerted",
+			open: []*model.GeofenceEvent{{
+				AircraftID: 7, GeofenceID: 1, EventType: model.GeofenceEventDwellExceeded,
+				EnteredAt: start, OccurredAt: start.Add(time.Minute),
+			}},
+			at:     2 * time.Minute,
+			inside: []uint{1},
+			want:   nil,
+		},
+		{
+			name:   "other aircraft are not affected",
+			open:   []*model.GeofenceEvent{{AircraftID: 8, GeofenceID: 2, EventType: model.GeofenceEventEntered, EnteredAt: start, OccurredAt: start}},
+			at:     time.Second,
+			inside: []uint{2},
+			want:   []trackedEvent{{2, model.GeofenceEventEntered}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewGeofenceTracker(&memoryGeofenceEventRepo{open: tt.open}, nil, 0)
+			if err := tracker.Load(); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			events, err := tracker.Track(7, positionAt(start.Add(tt.at)), trackerGeofences(tt.inside...))
+			if err != nil {
+				t.Fatalf("Track() error = %v", err)
+			}
+			if got := trackedEvents(events); !equalTrackedEvents(got, tt.want) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeofenceTrackerCloseStale(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		after   time.Duration // Sweep time relative to the last report
+		saveErr error
+		want    []trackedEvent
+		open    bool // Incursions still tracked after the sweep
+	}{
+		{"still reporting", time.Minute, nil, nil, true},
+		{"stopped reporting", incursionStaleAfter + time.Minute, nil, []trackedEvent{{2, model.GeofenceEventExited}, {3, model.GeofenceEventExited}}, false},
+		{"save failed", incursionStaleAfter + time.Minute, errors.New("database unavailable"), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryGeofenceEventRepo{}
+			tracker := NewGeofenceTracker(repo, nil, 0).(*geofenceTracker)
+
+			// Telemetry time is far behind the wall clock; staleness goes by when it was reported
+			reportedAt := time.Now()
+			if _, err := tracker.Track(7, positionAt(start), trackerGeofences(3, 2)); err != nil {
+				t.Fatalf("Track() error = %v", err)
+			}
+
+			repo.saveErr = tt.saveErr
+			events, err := tracker.closeStale(reportedAt.Add(tt.after))
+			if (err != nil) != (tt.saveErr != nil) {
+				t.Fatalf("closeStale() error = %v, want %v", err, tt.saveErr)
+			}
+			if got := trackedEvents(events); !equalTrackedEvents(got, tt.want) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+			for _, event := range events {
+				if !event.OccurredAt.Equal(start) || event.Latitude != 41 {
+					t.Errorf("exit event at %v (%f), want last position at %v", event.OccurredAt, event.Latitude, start)
+				}
+			}
+			if _, open := tracker.incursions[7]; open != tt.open {
+				t.Errorf("incursions tracked = %v, want %v", open, tt.open)
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/consumer"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/logging"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultTelemetryBatchSize       = 100
+	defaultTelemetryBatchMaxLatency = 200 * time.Millisecond
+	defaultTelemetryCommitWorkers   = 16
+)
+
+// ErrTelemetryBatcherStopped is returned when adding a row after the batcher started shutting down
+var ErrTelemetryBatcherStopped = errors.New("telemetry batcher stopped")
+
+// TelemetryBatcher accumulates telemetry rows and writes them to the database in batches
+type TelemetryBatcher interface {
+	Run(ctx context.Context)
+	Add(ctx context.Context, entry *consumer.StreamEntry, telemetry *model.Telemetry, afterCommit AfterCommitFunc) error
+	Stop()
+}
+
+// AfterCommitFunc runs the post-commit work of a row once its batch has been written (err is nil) or has failed.
+// The returned error is the outcome of the row's stream entry; entries that fail are left pending for retry.
+type AfterCommitFunc func(ctx context.Context, err error) error
+
+// BatchCompleter reports the outcome of a written batch's stream entries
+type BatchCompleter interface {
+	CompleteBatch(ctx context.Context, entries []*consumer.StreamEntry, errs []error)
+}
+
+// batchItem is a telemetry row waiting to be written along with its stream entry and post-commit work
+type batchItem struct {
+	entry       *consumer.StreamEntry
+	telemetry   *model.Telemetry
+	afterCommit AfterCommitFunc
+}
+
+// committedBatch collects the outcomes of a written batch's rows until all of its post-commit work is done
+type committedBatch struct {
+	entries   []*consumer.StreamEntry
+	errs      []error
+	mu        sync.Mutex
+	remaining int
+}
+
+// commitTask is the post-commit work of one row of a written batch
+type commitTask struct {
+	batch     *committedBatch
+	index     int
+	item      batchItem
+	commitErr error
+}
+
+type telemetryBatcher struct {
+	telemetryRepo repository.TelemetryRepository
+	completer     BatchCompleter
+	maxSize       int
+	maxLatency    time.Duration
+	items         chan batchItem
+	commitQueues  []chan commitTask // Post-commit work, sharded by aircraft ID so each aircraft's rows stay ordered
+	stop          chan struct{}     // Closed by Stop
+	stopOnce      sync.Once
+	done          chan struct{} // Closed when Run returns
+	mu            sync.RWMutex  // Held for reading while adding, for writing to set stopping
+	stopping      bool
+}
+
+// NewTelemetryBatcher creates a new telemetry batcher that flushes when maxSize rows are
+// queued or the oldest queued row has waited maxLatency, whichever happens first.
+// Post-commit work runs on commitWorkers goroutines; once all rows of a batch are done,
+// their entries are reported to completer together.
+func NewTelemetryBatcher(
+	telemetryRepo repository.TelemetryRepository,
+	completer BatchCompleter,
+	maxSize int,
+	maxLatency time.Duration,
+	commitWorkers int,
+) TelemetryBatcher {
+	if maxSize <= 0 {
+		maxSize = defaultTelemetryBatchSize
+	}
+	if maxLatency <= 0 {
+		maxLatency = defaultTelemetryBatchMaxLatency
+	}
+	if commitWorkers <= 0 {
+		commitWorkers = defaultTelemetryCommitWorkers
+	}
+
+	commitQueues := make([]chan commitTask, commitWorkers)
+	for i := range commitQueues {
+		commitQueues[i] = make(chan commitTask, maxSize)
+	}
+
+	return &telemetryBatcher{
+		telemetryRepo: telemetryRepo,
+		completer:     completer,
+		maxSize:       maxSize,
+		maxLatency:    maxLatency,
+		items:         make(chan batchItem, maxSize),
+		commitQueues:  commitQueues,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Add queues a telemetry row for entry. afterCommit is called from a commit worker once the row's
+// batch has been written or has failed. Blocks while the queue is full.
+// Returns ErrTelemetryBatcherStopped once the batcher is shutting down.
+func (b *telemetryBatcher) Add(ctx context.Context, entry *consumer.StreamEntry, telemetry *model.Telemetry, afterCommit AfterCommitFunc) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.stopping {
+		return ErrTelemetryBatcherStopped
+	}
+
+	select {
+	case b.items <- batchItem{entry: entry, telemetry: telemetry, afterCommit: afterCommit}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run collects queued rows and flushes them until ctx is cancelled or Stop is called.
+// Every row that was successfully added is flushed, and its post-commit work done, before Run returns.
+func (b *telemetryBatcher) Run(ctx context.Context) {
+	defer close(b.done)
+
+	// Writes, post-commit work and completion outlive ctx, so the final flush on shutdown is still written and reported
+	commitCtx := context.WithoutCancel(ctx)
+	var workers sync.WaitGroup
+	for _, queue := range b.commitQueues {
+		workers.Add(1)
+		go func(queue chan commitTask) {
+			defer workers.Done()
+			for task := range queue {
+				b.runAfterCommit(commitCtx, task)
+			}
+		}(queue)
+	}
+	defer func() {
+		for _, queue := range b.commitQueues {
+			close(queue)
+		}
+		workers.Wait()
+	}()
+
+	batch := make([]batchItem, 0, b.maxSize)
+
+	var timer *time.Timer
+	var timerC <-chan time.Time
+
+	flush := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, timerC = nil, nil
+		}
+		b.flush(commitCtx, batch)
+		batch = batch[:0]
+	}
+
+	add := func(item batchItem) {
+		batch = append(batch, item)
+		if len(batch) == 1 {
+			timer = time.NewTimer(b.maxLatency)
+			timerC = timer.C
+		}
+		if len(batch) >= b.maxSize {
+			flush()
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.shutdown(add)
+			flush()
+			return
+		case <-b.stop:
+			b.shutdown(add)
+			flush()
+			return
+		case item := <-b.items:
+			add(item)
+		case <-timerC:
+			flush()
+		}
+	}
+}
+
+// shutdown rejects further rows and passes every row already queued to add. Rows keep being
+// consumed while waiting for in-flight Add calls, so callers blocked on a full queue can finish.
+func (b *telemetryBatcher) shutdown(add func(item batchItem)) {
+	stopped := make(chan struct{})
+	go func() {
+		b.mu.Lock()
+		b.stopping = true
+		b.mu.Unlock()
+		close(stopped)
+	}()
+
+	for {
+		select {
+		case item := <-b.items:
+			add(item)
+		case <-stopped:
+			// No Add can be in progress now; take what is left
+			for {
+				select {
+				case item := <-b.items:
+					add(item)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Stop stops the batcher and waits for Run to write the remaining rows and finish their post-commit work.
+// Run must have been started; if it has not begun yet, it shuts down as soon as it does.
+func (b *telemetryBatcher) Stop() {
+	b.stopOnce.Do(func() { close(b.stop) })
+	<-b.done
+}
+
+// flush writes a batch and hands each row's result to the commit workers.
+// If the batch fails, rows are retried one by one so a single bad row doesn't fail the others.
+func (b *telemetryBatcher) flush(ctx context.Context, batch []batchItem) {
+	if len(batch) == 0 {
+		return
+	}
+
+	telemetries := make([]*model.Telemetry, len(batch))
+	for i, item := range batch {
+		telemetries[i] = item.telemetry
+	}
+
+	commitErrs := make([]error, len(batch))
+	if err := b.telemetryRepo.CreateBatch(ctx, telemetries); err == nil {
+		logging.Debug("Telemetry batch written", zap.Int("size", len(batch)))
+	} else {
+		logging.Error("Failed to write telemetry batch, retrying rows individually",
+			zap.Error(err),
+			zap.Int("size", len(batch)),
+		)
+		for i, item := range batch {
+			commitErrs[i] = b.telemetryRepo.Create(ctx, item.telemetry)
+		}
+	}
+
+	committed := &committedBatch{
+		entries:   make([]*consumer.StreamEntry, len(batch)),
+		errs:      make([]error, len(batch)),
+		remaining: len(batch),
+	}
+	for i, item := range batch {
+		committed.entries[i] = item.entry
+		queue := b.commitQueues[item.telemetry.AircraftID%uint(len(b.commitQueues))]
+		queue <- commitTask{batch: committed, index: i, item: item, commitErr: commitErrs[i]}
+	}
+}
+
+// runAfterCommit runs a row's post-commit work and completes its batch once it is the last row done
+func (b *telemetryBatcher) runAfterCommit(ctx context.Context, task commitTask) {
+	err := task.item.afterCommit(ctx, task.commitErr)
+
+	batch := task.batch
+	batch.mu.Lock()
+	batch.errs[task.index] = err
+	batch.remaining--
+	last := batch.remaining == 0
+	batch.mu.Unlock()
+
+	if last {
+		b.completer.CompleteBatch(ctx, batch.entries, batch.errs)
+	}
+}
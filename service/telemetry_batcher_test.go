@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/consumer"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+)
+
+// commitRecorder is a BatchCompleter that collects entry outcomes by aircraft ID
+type commitRecorder struct {
+	mu      sync.Mutex
+	results map[uint]error
+	batches int // CompleteBatch calls
+}
+
+func (c *commitRecorder) CompleteBatch(_ context.Context, entries []*consumer.StreamEntry, errs []error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.batches++
+	for i, entry := range entries {
+		aircraftID, _ := strconv.ParseUint(entry.ID, 10, 64)
+		c.results[uint(aircraftID)] = errs[i]
+	}
+}
+
+// add queues a row for aircraftID whose post-commit work passes the commit error through
+func add(ctx context.Context, b TelemetryBatcher, aircraftID uint) error {
+	entry := &consumer.StreamEntry{ID: strconv.FormatUint(uint64(aircraftID), 10)}
+	return b.Add(ctx, entry, &model.Telemetry{AircraftID: aircraftID}, func(_ context.Context, err error) error { return err })
+}
+
+func TestTelemetryBatcherFlush(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxSize    int
+		maxLatency time.Duration
+		rows       int
+		wantBatch  int
+	}{
+		{"full batch", 3, time.Hour, 3, 3},
+		{"latency", 100, 10 * time.Millisecond, 2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryTelemetryRepo{rows: make(map[uint]bool), written: make(chan []*model.Telemetry, 1)}
+			b := NewTelemetryBatcher(repo, &commitRecorder{results: make(map[uint]error)}, tt.maxSize, tt.maxLatency, 4)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go b.Run(ctx)
+
+			for i := 0; i < tt.rows; i++ {
+				if err := add(ctx, b, uint(i)); err != nil {
+					t.Fatalf("Add() error = %v", err)
+				}
+			}
+
+			select {
+			case batch := <-repo.written:
+				if len(batch) != tt.wantBatch {
+					t.Errorf("batch size = %d, want %d", len(batch), tt.wantBatch)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("batch was not written")
+			}
+			b.Stop()
+		})
+	}
+}
+
+func TestTelemetryBatcherShutdown(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(b TelemetryBatcher, cancel context.CancelFunc)
+	}{
+		{"stop", func(b TelemetryBatcher, _ context.CancelFunc) { b.Stop() }},
+		{"context cancelled", func(b TelemetryBatcher, cancel context.CancelFunc) {
+			cancel()
+			b.Stop()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryTelemetryRepo{rows: make(map[uint]bool)}
+			commits := &commitRecorder{results: make(map[uint]error)}
+			// A long latency, so rows are only written on shutdown
+			b := NewTelemetryBatcher(repo, commits, 100, time.Hour, 4)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go b.Run(ctx)
+
+			const rows = 10
+			for i := uint(0); i < rows; i++ {
+				if err := add(context.Background(), b, i); err != nil {
+					t.Fatalf("Add() error = %v", err)
+				}
+			}
+
+			tt.stop(b, cancel)
+
+			if len(commits.results) != rows {
+				t.Fatalf("committed rows = %d, want %d", len(commits.results), rows)
+			}
+			for aircraftID, err := range commits.results {
+				if err != nil || !repo.rows[aircraftID] {
+					t.Errorf("row %d: written = %v, commit error = %v", aircraftID, repo.rows[aircraftID], err)
+				}
+			}
+
+			err := add(context.Background(), b, rows)
+			if !errors.Is(err, ErrTelemetryBatcherStopped) {
+				t.Errorf("Add() after shutdown error = %v, want %v", err, ErrTelemetryBatcherStopped)
+			}
+		})
+	}
+}
+
+func TestTelemetryBatcherStopBeforeRun(t *testing.T) {
+	repo := &memoryTelemetryRepo{rows: make(map[uint]bool)}
+	commits := &commitRecorder{results: make(map[uint]error)}
+	b := NewTelemetryBatcher(repo, commits, 100, time.Hour, 4)
+
+	if err := add(context.Background(), b, 1); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		b.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatal("Stop() returned before Run wrote the queued row")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	go b.Run(context.Background())
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop() did not return after Run started")
+	}
+	if err, ok := commits.results[1]; !ok || err != nil {
+		t.Errorf("queued row committed = %v, error = %v", ok, err)
+	}
+}
+
+func TestTelemetryBatcherShutdownUnblocksAdd(t *testing.T) {
+	repo := &memoryTelemetryRepo{rows: make(map[uint]bool)}
+	commits := &commitRecorder{results: make(map[uint]error)}
+	// The queue holds maxSize rows, so with no Run the extra Adds block on a full queue
+	const maxSize, rows = 2, 6
+	b := NewTelemetryBatcher(repo, commits, maxSize, time.Hour, 4)
+
+	var added sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	for i := uint(0); i < rows; i++ {
+		added.Add(1)
+		go func() {
+			defer added.Done()
+			if err := add(context.Background(), b, i); err == nil {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			} else if !errors.Is(err, ErrTelemetryBatcherStopped) {
+				t.Errorf("Add() error = %v", err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	go b.Run(context.Background())
+	b.Stop()
+	added.Wait()
+
+	// Adds that were blocked when shutdown began are accepted, and every accepted row is committed
+	if accepted != rows || len(commits.results) != rows {
+		t.Errorf("accepted rows = %d, committed rows = %d, want %d", accepted, len(commits.results), rows)
+	}
+}
+
+func TestTelemetryBatcherRetriesRowsIndividually(t *testing.T) {
+	rowErr := errors.New("duplicate key")
+	repo := &memoryTelemetryRepo{
+		rows:     make(map[uint]bool),
+		batchErr: errors.New("batch failed"),
+		rowErrs:  map[uint]error{2: rowErr},
+	}
+	commits := &commitRecorder{results: make(map[uint]error)}
+	b := NewTelemetryBatcher(repo, commits, 3, time.Hour, 4)
+	go b.Run(context.Background())
+
+	for i := uint(1); i <= 3; i++ {
+		if err := add(context.Background(), b, i); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	b.Stop()
+
+	want := map[uint]error{1: nil, 2: rowErr, 3: nil}
+	for aircraftID, wantErr := range want {
+		err, ok := commits.results[aircraftID]
+		if !ok || !errors.Is(err, wantErr) {
+			t.Errorf("row %d commit error = %v (committed %v), want %v", aircraftID, err, ok, wantErr)
+		}
+	}
+}
+
+func TestTelemetryBatcherCompletesBatchTogether(t *testing.T) {
+	repo := &memoryTelemetryRepo{rows: make(map[uint]bool)}
+	commits := &commitRecorder{results: make(map[uint]error)}
+	b := NewTelemetryBatcher(repo, commits, 5, time.Hour, 4)
+	go b.Run(context.Background())
+
+	for i := uint(1); i <= 5; i++ {
+		if err := add(context.Background(), b, i); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	b.Stop()
+
+	if commits.batches != 1 || len(commits.results) != 5 {
+		t.Errorf("CompleteBatch calls = %d with %d entries, want 1 with 5", commits.batches, len(commits.results))
+	}
+}
+
+func TestTelemetryBatcherAfterCommitDoesNotBlockWrites(t *testing.T) {
+	repo := &memoryTelemetryRepo{rows: make(map[uint]bool), written: make(chan []*model.Telemetry, 2)}
+	commits := &commitRecorder{results: make(map[uint]error)}
+	b := NewTelemetryBatcher(repo, commits, 1, time.Hour, 2)
+	go b.Run(context.Background())
+
+	// Aircraft 1 and 2 land on different commit workers; the first one's post-commit work is stuck
+	release := make(chan struct{})
+	blocked := &consumer.StreamEntry{ID: "1"}
+	err := b.Add(context.Background(), blocked, &model.Telemetry{AircraftID: 1}, func(context.Context, error) error {
+		<-release
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := add(context.Background(), b, 2); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-repo.written:
+		case <-time.After(time.Second):
+			t.Fatal("batch was not written while post-commit work was blocked")
+		}
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		commits.mu.Lock()
+		_, done := commits.results[2]
+		commits.mu.Unlock()
+		if done {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("second batch was not completed while the first was blocked")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	close(release)
+	b.Stop()
+	if _, ok := commits.results[1]; !ok {
+		t.Error("blocked batch was not completed on Stop")
+	}
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/logging"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/redis"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/repository"
+	"go.uber.org/zap"
+)
+
+const defaultThresholdRefreshInterval = 5 * time.Minute
+
+// ThresholdCache serves threshold lookups from memory. It implements ThresholdRepository so it can
+// be used in place of the database repository. Returned thresholds are shared and must not be modified.
+type ThresholdCache interface {
+	repository.ThresholdRepository
+	Refresh() error
+	WatchChanges(ctx context.Context)
+}
+
+type thresholdCache struct {
+	thresholdRepo   repository.ThresholdRepository
+	redisClient     redis.Client
+	controlChannel  string
+	refreshInterval time.Duration
+	mu              sync.RWMutex
+	byAircraft      map[uint]map[string]*model.Threshold // aircraft ID -> metric name -> threshold
+	defaults        map[string]*model.Threshold          // metric name -> default threshold
+	generation      uint64                               // Incremented when a full load starts and when an aircraft is reloaded
+	loaded          uint64                               // Generation of the applied full load
+	refreshed       map[uint]uint64                      // aircraft ID -> generation of its latest reload
+}
+
+// NewThresholdCache creates a new threshold cache over the given repository.
+// Call Refresh to load it and WatchChanges to reload it when a control message arrives on controlChannel
+// and every refreshInterval.
+func NewThresholdCache(
+	thresholdRepo repository.ThresholdRepository,
+	redisClient redis.Client,
+	controlChannel string,
+	refreshInterval time.Duration,
+) ThresholdCache {
+	if refreshInterval <= 0 {
+		refreshInterval = defaultThresholdRefreshInterval
+	}
+
+	return &thresholdCache{
+		thresholdRepo:   thresholdRepo,
+		redisClient:     redisClient,
+		controlChannel:  controlChannel,
+		refreshInterval: refreshInterval,
+		byAircraft:      make(map[uint]map[string]*model.Threshold),
+		defaults:        make(map[string]*model.Threshold),
+		refreshed:       make(map[uint]uint64),
+	}
+}
+
+// Refresh reloads all thresholds from the database. Aircraft reloaded while it runs keep their newer
+// thresholds, and a load that finishes after a later one is dropped.
+func (c *thresholdCache) Refresh() error {
+	c.mu.Lock()
+	c.generation++
+	generation := c.generation
+	c.mu.Unlock()
+
+	thresholds, err := c.thresholdRepo.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+	sortThresholds(thresholds)
+
+	byAircraft := make(map[uint]map[string]*model.Threshold)
+	defaults := make(map[string]*model.Threshold)
+	for _, threshold := range thresholds {
+		if threshold.AircraftID == nil {
+			if _, ok := defaults[threshold.MetricName]; threshold.IsDefault && !ok {
+				defaults[threshold.MetricName] = threshold
+			}
+			continue
+		}
+		addThreshold(byAircraft, *threshold.AircraftID, threshold)
+	}
+
+	c.mu.Lock()
+	if generation < c.loaded {
+		c.mu.Unlock()
+		return nil
+	}
+	for aircraftID, refreshed := range c.refreshed {
+		if refreshed < generation {
+			delete(c.refreshed, aircraftID)
+			continue
+		}
+		if metrics, ok := c.byAircraft[aircraftID]; ok {
+			byAircraft[aircraftID] = metrics
+		} else {
+			delete(byAircraft, aircraftID)
+		}
+	}
+	c.byAircraft = byAircraft
+	c.defaults = defaults
+	c.loaded = generation
+	c.mu.Unlock()
+
+	logging.Info("Threshold cache loaded",
+		zap.Int("thresholds", len(thresholds)),
+		zap.Int("aircraft", len(byAircraft)),
+		zap.Int("defaults", len(defaults)),
+	)
+
+	return nil
+}
+
+// refreshAircraft reloads the thresholds of a single aircraft
+func (c *thresholdCache) refreshAircraft(aircraftID uint) error {
+	thresholds, err := c.thresholdRepo.GetByAircraftID(aircraftID)
+	if err != nil {
+		return fmt.Errorf("failed to load thresholds for aircraft %d: %w", aircraftID, err)
+	}
+	sortThresholds(thresholds)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.byAircraft, aircraftID)
+	for _, threshold := range thresholds {
+		addThreshold(c.byAircraft, aircraftID, threshold)
+	}
+	c.generation++
+	c.refreshed[aircraftID] = c.generation
+
+	return nil
+}
+
+// WatchChanges reloads the cache when a threshold control message arrives and on every refresh interval
+func (c *thresholdCache) WatchChanges(ctx context.Context) {
+	go listenControlChannel(ctx, c.redisClient, c.controlChannel, c.handleControl)
+
+	ticker := time.NewTicker(c.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Refresh(); err != nil {
+				logging.Error("Failed to refresh threshold cache", zap.Error(err))
+			}
+		}
+	}
+}
+
+// handleControl reloads the thresholds named by a threshold control message
+func (c *thresholdCache) handleControl(message model.ControlMessage) {
+	if message.Type != model.ControlInvalidateThresholds {
+		return
+	}
+
+	var err error
+	if message.AircraftID != nil {
+		err = c.refreshAircraft(*message.AircraftID)
+	} else {
+		err = c.Refresh()
+	}
+	if err != nil {
+		logging.Error("Failed to refresh threshold cache", zap.Error(err))
+	}
+}
+
+// GetByAircraftID retrieves all thresholds for a specific aircraft
+func (c *thresholdCache) GetByAircraftID(aircraftID uint) ([]*model.Threshold, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	thresholds := make([]*model.Threshold, 0, len(c.byAircraft[aircraftID]))
+	for _, threshold := range c.byAircraft[aircraftID] {
+		thresholds = append(thresholds, threshold)
+	}
+	sortThresholds(thresholds)
+	return thresholds, nil
+}
+
+// GetDefaults retrieves all default (global) thresholds
+func (c *thresholdCache) GetDefaults() ([]*model.Threshold, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	thresholds := make([]*model.Threshold, 0, len(c.defaults))
+	for _, threshold := range c.defaults {
+		thresholds = append(thresholds, threshold)
+	}
+	sortThresholds(thresholds)
+	return thresholds, nil
+}
+
+// GetByAircraftIDAndMetric retrieves a specific threshold for an aircraft and metric
+// Falls back to default if aircraft-specific threshold doesn't exist
+func (c *thresholdCache) GetByAircraftIDAndMetric(aircraftID uint, metricName string) (*model.Threshold, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if threshold, ok := c.byAircraft[aircraftID][metricName]; ok {
+		return threshold, nil
+	}
+	return c.defaults[metricName], nil
+}
+
+// GetAll retrieves all aircraft-specific and default thresholds
+func (c *thresholdCache) GetAll() ([]*model.Threshold, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	thresholds := make([]*model.Threshold, 0, len(c.defaults))
+	for _, threshold := range c.defaults {
+		thresholds = append(thresholds, threshold)
+	}
+	for _, metrics := range c.byAircraft {
+		for _, threshold := range metrics {
+			thresholds = append(thresholds, threshold)
+		}
+	}
+	sortThresholds(thresholds)
+	return thresholds, nil
+}
+
+// addThreshold registers a threshold for an aircraft, keeping the first one per metric like the database lookup
+func addThreshold(byAircraft map[uint]map[string]*model.Threshold, aircraftID uint, threshold *model.Threshold) {
+	metrics := byAircraft[aircraftID]
+	if metrics == nil {
+		metrics = make(map[string]*model.Threshold)
+		byAircraft[aircraftID] = metrics
+	}
+	if _, ok := metrics[threshold.MetricName]; !ok {
+		metrics[threshold.MetricName] = threshold
+	}
+}
+
+// sortThresholds orders thresholds by ID
+func sortThresholds(thresholds []*model.Threshold) {
+	sort.Slice(thresholds, func(i, j int) bool {
+		return thresholds[i].ID < thresholds[j].ID
+	})
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"gorm.io/gorm"
+)
+
+// speedLimit builds a ground speed threshold; aircraftID 0 makes it the default
+func speedLimit(id, aircraftID uint, max float64) *model.Threshold {
+	threshold := &model.Threshold{
+		Model:      gorm.Model{ID: id},
+		MetricName: string(model.MetricGroundSpeed),
+		MaxValue:   float(max),
+	}
+	if aircraftID == 0 {
+		threshold.IsDefault = true
+	} else {
+		threshold.AircraftID = &aircraftID
+	}
+	return threshold
+}
+
+// cachedSpeedLimits returns the cached ground speed limit of aircraft 1, 2 and 3
+func cachedSpeedLimits(t *testing.T, cache ThresholdCache) [3]float64 {
+	t.Helper()
+	var limits [3]float64
+	for i := range limits {
+		threshold, err := cache.GetByAircraftIDAndMetric(uint(i+1), string(model.MetricGroundSpeed))
+		if err != nil || threshold == nil {
+			t.Fatalf("GetByAircraftIDAndMetric(%d) = %v, %v", i+1, threshold, err)
+		}
+		limits[i] = *threshold.MaxValue
+	}
+	return limits
+}
+
+func TestThresholdCacheInvalidation(t *testing.T) {
+	aircraft1 := uint(1)
+	tests := []struct {
+		name    string
+		updated []*model.Threshold
+		message model.ControlMessage
+		want    [3]float64 // Limits of aircraft 1, 2 and 3; aircraft 3 has no thresholds of its own
+	}{
+		{
+			name:    "aircraft message reloads only that aircraft",
+			updated: []*model.Threshold{speedLimit(1, 0, 450), speedLimit(2, 1, 350), speedLimit(3, 2, 250)},
+			message: model.ControlMessage{Type: model.ControlInvalidateThresholds, AircraftID: &aircraft1},
+			want:    [3]float64{350, 300, 500},
+		},
+		{
+			name:    "aircraft message drops removed thresholds",
+			updated: []*model.Threshold{speedLimit(1, 0, 500), speedLimit(3, 2, 300)},
+			message: model.ControlMessage{Type: model.ControlInvalidateThresholds, AircraftID: &aircraft1},
+			want:    [3]float64{500, 300, 500},
+		},
+		{
+			name:    "message without aircraft reloads everything",
+			updated: []*model.Threshold{speedLimit(1, 0, 450), speedLimit(2, 1, 350), speedLimit(3, 2, 250)},
+			message: model.ControlMessage{Type: model.ControlInvalidateThresholds},
+			want:    [3]float64{350, 250, 450},
+		},
+		{
+			name:    "other message types are ignored",
+			updated: []*model.Threshold{speedLimit(1, 0, 450), speedLimit(2, 1, 350), speedLimit(3, 2, 250)},
+			message: model.ControlMessage{Type: model.ControlInvalidateAircraft},
+			want:    [3]float64{400, 300, 500},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &thresholdTable{}
+			repo.set(speedLimit(1, 0, 500), speedLimit(2, 1, 400), speedLimit(3, 2, 300))
+			cache := NewThresholdCache(repo, nil, "", 0)
+			if err := cache.Refresh(); err != nil {
+				t.Fatalf("Refresh() error = %v", err)
+			}
+			if got := cachedSpeedLimits(t, cache); got != [3]float64{400, 300, 500} {
+				t.Fatalf("limits after Refresh() = %v, want [400 300 500]", got)
+			}
+
+			repo.set(tt.updated...)
+			// Lookups are served from memory until the cache is invalidated
+			if got := cachedSpeedLimits(t, cache); got != [3]float64{400, 300, 500} {
+				t.Fatalf("limits before invalidation = %v, want [400 300 500]", got)
+			}
+
+			cache.(*thresholdCache).handleControl(tt.message)
+			if got := cachedSpeedLimits(t, cache); got != tt.want {
+				t.Errorf("limits after invalidation = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThresholdCacheKeepsThresholdsWhenReloadFails(t *testing.T) {
+	repo := &thresholdTable{}
+	repo.set(speedLimit(1, 0, 500), speedLimit(2, 1, 400), speedLimit(3, 2, 300))
+	cache := NewThresholdCache(repo, nil, "", 0)
+	if err := cache.Refresh(); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	repo.err = errors.New("database unavailable")
+	aircraft1 := uint(1)
+	cache.(*thresholdCache).handleControl(model.ControlMessage{Type: model.ControlInvalidateThresholds, AircraftID: &aircraft1})
+	cache.(*thresholdCache).handleControl(model.ControlMessage{Type: model.ControlInvalidateThresholds})
+
+	if got := cachedSpeedLimits(t, cache); got != [3]float64{400, 300, 500} {
+		t.Errorf("limits after failed reloads = %v, want [400 300 500]", got)
+	}
+	if repo.loads != 3 {
+		t.Errorf("repository loads = %d, want 3", repo.loads)
+	}
+}
+
+// pausedThresholdTable is a thresholdTable whose GetAll reads the rows, then sends a gate on loaded
+// and returns once the gate is closed
+type pausedThresholdTable struct {
+	*thresholdTable
+	loaded chan chan struct{}
+}
+
+func (r *pausedThresholdTable) GetAll() ([]*model.Threshold, error) {
+	thresholds, err := r.thresholdTable.GetAll()
+	gate := make(chan struct{})
+	r.loaded <- gate
+	<-gate
+	return thresholds, err
+}
+
+// startRefresh runs Refresh in the background and returns the gate of its load and a channel closed when it returns
+func startRefresh(t *testing.T, cache ThresholdCache, repo *pausedThresholdTable) (chan struct{}, chan struct{}) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := cache.Refresh(); err != nil {
+			t.Errorf("Refresh() error = %v", err)
+		}
+	}()
+	return <-repo.loaded, done
+}
+
+func TestThresholdCacheKeepsAircraftReloadedDuringRefresh(t *testing.T) {
+	repo := &pausedThresholdTable{thresholdTable: &thresholdTable{}, loaded: make(chan chan struct{})}
+	repo.set(speedLimit(1, 0, 500), speedLimit(2, 1, 400), speedLimit(3, 2, 300))
+	cache := NewThresholdCache(repo, nil, "", 0)
+
+	gate, done := startRefresh(t, cache, repo)
+	// The thresholds change and aircraft 1 is reloaded after the full load has read the old rows
+	repo.set(speedLimit(1, 0, 450), speedLimit(2, 1, 350), speedLimit(3, 2, 250))
+	aircraft1 := uint(1)
+	cache.(*thresholdCache).handleControl(model.ControlMessage{Type: model.ControlInvalidateThresholds, AircraftID: &aircraft1})
+	close(gate)
+	<-done
+
+	if got := cachedSpeedLimits(t, cache); got != [3]float64{350, 300, 500} {
+		t.Errorf("limits = %v, want [350 300 500]", got)
+	}
+
+	// A later full load applies the aircraft again and forgets the reload
+	gate, done = startRefresh(t, cache, repo)
+	close(gate)
+	<-done
+	if got := cachedSpeedLimits(t, cache); got != [3]float64{350, 250, 450} {
+		t.Errorf("limits after the next Refresh() = %v, want [350 250 450]", got)
+	}
+	if refreshed := len(cache.(*thresholdCache).refreshed); refreshed != 0 {
+		t.Errorf("%d aircraft reloads kept, want 0", refreshed)
+	}
+}
+
+func TestThresholdCacheDropsRefreshFinishingAfterNewerOne(t *testing.T) {
+	repo := &pausedThresholdTable{thresholdTable: &thresholdTable{}, loaded: make(chan chan struct{})}
+	repo.set(speedLimit(1, 0, 500), speedLimit(2, 1, 400), speedLimit(3, 2, 300))
+	cache := NewThresholdCache(repo, nil, "", 0)
+
+	olderGate, olderDone := startRefresh(t, cache, repo)
+	repo.set(speedLimit(1, 0, 450), speedLimit(2, 1, 350), speedLimit(3, 2, 250))
+	newerGate, newerDone := startRefresh(t, cache, repo)
+	close(newerGate)
+	<-newerDone
+	close(olderGate)
+	<-olderDone
+
+	if got := cachedSpeedLimits(t, cache); got != [3]float64{350, 250, 450} {
+		t.Errorf("limits = %v, want the newer load [350 250 450]", got)
+	}
+}
@@ -2,6 +2,9 @@ package service
 
 import (
 	"fmt"
+	"math"
+	"sync"
+	"time"
 
 	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
 	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/logging"
@@ -9,36 +12,116 @@ import (
 	"go.uber.org/zap"
 )
 
+// thresholdStateTTL is how long per-aircraft threshold state is kept without new samples;
+// older state is discarded so a breach doesn't count as sustained across a gap in telemetry
+const thresholdStateTTL = 5 * time.Minute
+
+// checkedSampleRetention is how long, in telemetry time behind an aircraft's latest sample, the result of
+// a checked sample is kept. It is longer than a failed entry stays pending before it is redelivered.
+const checkedSampleRetention = 15 * time.Minute
+
+// headingSectorBound keys the state of a heading sector, which is breached on either side
+const headingSectorBound model.BoundType = "sector"
+
 // ThresholdService handles threshold checking operations
 type ThresholdService interface {
-	CheckThresholds(aircraftID uint, telemetry *model.TelemetryDTO) (bool, []string) // returns (hasViolation, violations)
+	CheckThresholds(aircraftID uint, telemetry *model.TelemetryDTO) (bool, []model.Violation) // returns (hasViolation, violations)
+}
+
+// boundKey identifies one side of a metric's threshold
+type boundKey struct {
+	metric string
+	bound  model.BoundType
+}
+
+// boundState tracks an ongoing breach of one threshold bound
+type boundState struct {
+	thresholdID uint
+	since       time.Time // First sample of the breach
+	last        time.Time // Latest sample counted
+	samples     int       // Consecutive breaching samples
+	raised      bool      // Sustain conditions were met; cleared only at the clear level
+}
+
+// thresholdSample is the metric values of one telemetry sample
+type thresholdSample struct {
+	at     time.Time
+	values map[model.MetricName]float64
+}
+
+// checkedKey identifies a checked sample by its time and metric values
+type checkedKey struct {
+	at     int64 // Unix nanoseconds
+	values [4]float64
+}
+
+// aircraftThresholdState holds the threshold state of one aircraft
+type aircraftThresholdState struct {
+	lastSeen     time.Time // Latest sample time; guarded by the service's mutex
+	lastReported time.Time // Wall-clock time of the latest sample, used for eviction; guarded by the service's mutex
+	mu           sync.Mutex
+	previous     *thresholdSample // Latest sample, used for rates of change
+	bounds       map[boundKey]*boundState
+	checked      map[checkedKey][]model.Violation // Results of recent samples, returned again on redelivery
+	checkedOrder []checkedKey                     // Keys of checked in the order they were added
 }
 
 type thresholdService struct {
 	thresholdRepo repository.ThresholdRepository
+	mu            sync.Mutex
+	states        map[uint]*aircraftThresholdState // aircraft ID -> state
 }
 
 // NewThresholdService creates a new threshold service
 func NewThresholdService(thresholdRepo repository.ThresholdRepository) ThresholdService {
 	return &thresholdService{
 		thresholdRepo: thresholdRepo,
+		states:        make(map[uint]*aircraftThresholdState),
 	}
 }
 
-// CheckThresholds checks if telemetry values violate any thresholds
-// Returns (hasViolation, list of violation descriptions)
-func (s *thresholdService) CheckThresholds(aircraftID uint, telemetry *model.TelemetryDTO) (bool, []string) {
-	var violations []string
+// CheckThresholds checks if telemetry values and their rates of change violate any thresholds, applying
+// hysteresis and sustain conditions per aircraft. Returns (hasViolation, list of violations in metric order).
+// A redelivered sample, e.g. after its telemetry row failed to save, gets the result it got the first time
+// without updating the state again.
+func (s *thresholdService) CheckThresholds(aircraftID uint, telemetry *model.TelemetryDTO) (bool, []model.Violation) {
+	var violations []model.Violation
+	now := telemetry.Timestamp.Time
 
-	// Check each metric
-	metrics := map[string]float64{
-		string(model.MetricGroundSpeed): telemetry.GroundSpeed,
-		string(model.MetricAltitude):    telemetry.Altitude,
-		string(model.MetricClimbRate):   telemetry.ClimbRate,
-		string(model.MetricHeading):     telemetry.Heading,
+	// Check each metric, in a fixed order so violations are reported deterministically
+	metrics := []struct {
+		name  model.MetricName
+		value float64
+	}{
+		{model.MetricGroundSpeed, telemetry.GroundSpeed},
+		{model.MetricAltitude, telemetry.Altitude},
+		{model.MetricClimbRate, telemetry.ClimbRate},
+		{model.MetricHeading, telemetry.Heading},
 	}
 
-	for metricName, value := range metrics {
+	state := s.stateFor(aircraftID, now, time.Now())
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	key := checkedKey{
+		at:     now.UnixNano(),
+		values: [4]float64{telemetry.GroundSpeed, telemetry.Altitude, telemetry.ClimbRate, telemetry.Heading},
+	}
+	if checked, ok := state.checked[key]; ok {
+		violations = append(violations, checked...)
+		return len(violations) > 0, violations
+	}
+	defer func() { state.remember(key, violations) }()
+
+	current := &thresholdSample{at: now, values: make(map[model.MetricName]float64, len(metrics))}
+
+	for _, metric := range metrics {
+		metricName, value := string(metric.name), metric.value
+		if metric.name == model.MetricHeading {
+			value = model.NormalizeHeading(value)
+		}
+		current.values[metric.name] = value
+
 		threshold, err := s.thresholdRepo.GetByAircraftIDAndMetric(aircraftID, metricName)
 		if err != nil {
 			logging.Error("Failed to get threshold", zap.Error(err), zap.String("metric_name", metricName), zap.Uint("aircraft_id", aircraftID))
@@ -50,14 +133,219 @@ func (s *thresholdService) CheckThresholds(aircraftID uint, telemetry *model.Tel
 			continue
 		}
 
-		if threshold.MaxValue != nil && value > *threshold.MaxValue {
-			violations = append(violations, fmt.Sprintf("%s exceeds maximum: %.2f > %.2f", metricName, value, *threshold.MaxValue))
+		if sector, ok := threshold.Sector(); ok {
+			key := boundKey{metric: metricName, bound: headingSectorBound}
+			clearSector := threshold.ClearSector()
+			if state.check(key, threshold, now, !sector.Contains(value), !clearSector.Contains(value)) {
+				violations = append(violations, sectorViolation(metricName, value, sector, clearSector, threshold))
+			}
+		} else if threshold.MaxValue != nil {
+			clearLevel := *threshold.MaxValue
+			if threshold.ClearMaxValue != nil {
+				clearLevel = *threshold.ClearMaxValue
+			}
+			key := boundKey{metric: metricName, bound: model.BoundTypeMax}
+			if state.check(key, threshold, now, value > *threshold.MaxValue, value > clearLevel) {
+				message := fmt.Sprintf("%s exceeds maximum: %.2f > %.2f", metricName, value, *threshold.MaxValue)
+				if value <= *threshold.MaxValue {
+					message = fmt.Sprintf("%s above clear level: %.2f > %.2f", metricName, value, clearLevel)
+				}
+				violations = append(violations, thresholdViolation(metricName, value, *threshold.MaxValue, model.BoundTypeMax,
+					threshold.SeverityFor(value-*threshold.MaxValue), message))
+			}
+		}
+
+		if _, ok := threshold.Sector(); !ok && threshold.MinValue != nil {
+			clearLevel := *threshold.MinValue
+			if threshold.ClearMinValue != nil {
+				clearLevel = *threshold.ClearMinValue
+			}
+			key := boundKey{metric: metricName, bound: model.BoundTypeMin}
+			if state.check(key, threshold, now, value < *threshold.MinValue, value < clearLevel) {
+				message := fmt.Sprintf("%s below minimum: %.2f < %.2f", metricName, value, *threshold.MinValue)
+				if value >= *threshold.MinValue {
+					message = fmt.Sprintf("%s below clear level: %.2f < %.2f", metricName, value, clearLevel)
+				}
+				violations = append(violations, thresholdViolation(metricName, value, *threshold.MinValue, model.BoundTypeMin,
+					threshold.SeverityFor(*threshold.MinValue-value), message))
+			}
+		}
+
+		if threshold.MaxRate == nil && threshold.MinRate == nil {
+			continue
+		}
+		rate, ok := state.rate(metric.name, value, now)
+		if !ok {
+			continue
 		}
 
-		if threshold.MinValue != nil && value < *threshold.MinValue {
-			violations = append(violations, fmt.Sprintf("%s below minimum: %.2f < %.2f", metricName, value, *threshold.MinValue))
+		if threshold.MaxRate != nil {
+			key := boundKey{metric: metricName, bound: model.BoundTypeMaxRate}
+			if state.check(key, threshold, now, rate > *threshold.MaxRate, rate > *threshold.MaxRate) {
+				violations = append(violations, thresholdViolation(metricName, rate, *threshold.MaxRate, model.BoundTypeMaxRate,
+					threshold.RateSeverityFor(rate-*threshold.MaxRate),
+					fmt.Sprintf("%s rate of change exceeds maximum: %.2f/s > %.2f/s", metricName, rate, *threshold.MaxRate)))
+			}
 		}
+
+		if threshold.MinRate != nil {
+			key := boundKey{metric: metricName, bound: model.BoundTypeMinRate}
+			if state.check(key, threshold, now, rate < *threshold.MinRate, rate < *threshold.MinRate) {
+				violations = append(violations, thresholdViolation(metricName, rate, *threshold.MinRate, model.BoundTypeMinRate,
+					threshold.RateSeverityFor(*threshold.MinRate-rate),
+					fmt.Sprintf("%s rate of change below minimum: %.2f/s < %.2f/s", metricName, rate, *threshold.MinRate)))
+			}
+		}
+	}
+
+	// Out-of-order samples are checked but don't replace the latest one
+	if state.previous == nil || now.After(state.previous.at) {
+		state.previous = current
 	}
 
 	return len(violations) > 0, violations
 }
+
+// stateFor returns the aircraft's threshold state, discarding it after a gap in the aircraft's telemetry.
+// reportedAt is the wall-clock time of the sample. The state must be locked before use; different
+// aircraft are checked concurrently.
+func (s *thresholdService) stateFor(aircraftID uint, now, reportedAt time.Time) *aircraftThresholdState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[aircraftID]
+	if !ok || now.Sub(state.lastSeen) > thresholdStateTTL {
+		s.evictStale(reportedAt)
+		state = &aircraftThresholdState{
+			lastSeen: now,
+			bounds:   make(map[boundKey]*boundState),
+			checked:  make(map[checkedKey][]model.Violation),
+		}
+		s.states[aircraftID] = state
+	}
+	if now.After(state.lastSeen) {
+		state.lastSeen = now
+	}
+	state.lastReported = reportedAt
+	return state
+}
+
+// evictStale drops the state of aircraft that have not reported for thresholdStateTTL of wall-clock time.
+// Must be called with s.mu held.
+func (s *thresholdService) evictStale(now time.Time) {
+	for aircraftID, state := range s.states {
+		if now.Sub(state.lastReported) > thresholdStateTTL {
+			delete(s.states, aircraftID)
+		}
+	}
+}
+
+// remember keeps the result of a checked sample and drops results older than checkedSampleRetention
+// behind the latest sample
+func (a *aircraftThresholdState) remember(key checkedKey, violations []model.Violation) {
+	a.checked[key] = violations
+	a.checkedOrder = append(a.checkedOrder, key)
+
+	oldest := a.previous.at.Add(-checkedSampleRetention).UnixNano()
+	expired := 0
+	for expired < len(a.checkedOrder) && a.checkedOrder[expired].at < oldest {
+		delete(a.checked, a.checkedOrder[expired])
+		expired++
+	}
+	a.checkedOrder = a.checkedOrder[expired:]
+}
+
+// rate returns the per-second change of a metric since the previous sample.
+// Returns false if there is no earlier sample to compare against.
+func (a *aircraftThresholdState) rate(metric model.MetricName, value float64, now time.Time) (float64, bool) {
+	if a.previous == nil || !now.After(a.previous.at) {
+		return 0, false
+	}
+	previous, ok := a.previous.values[metric]
+	if !ok {
+		return 0, false
+	}
+
+	delta := value - previous
+	if metric == model.MetricHeading {
+		delta = model.HeadingDelta(previous, value)
+	}
+	return delta / now.Sub(a.previous.at).Seconds(), true
+}
+
+// check updates the state of a threshold bound with a sample and reports whether a violation is raised.
+// triggered is whether the value is past the limit, uncleared whether it is still past the clear level.
+func (a *aircraftThresholdState) check(key boundKey, threshold *model.Threshold, now time.Time, triggered, uncleared bool) bool {
+	bound, ok := a.bounds[key]
+	if ok && bound.thresholdID != threshold.ID {
+		// The threshold was replaced; start over
+		delete(a.bounds, key)
+		bound, ok = nil, false
+	}
+
+	// A redelivered or late sample is not another consecutive sample; it neither advances nor resets the breach
+	if ok && !now.After(bound.last) {
+		return bound.raised
+	}
+
+	breached := triggered || (ok && bound.raised && uncleared)
+	if !breached {
+		delete(a.bounds, key)
+		return false
+	}
+
+	if !ok {
+		bound = &boundState{thresholdID: threshold.ID, since: now}
+		a.bounds[key] = bound
+	}
+	bound.last = now
+	bound.samples++
+	// Rates span two samples already, so their breaches are raised without sustain conditions
+	if !bound.raised && (key.bound.IsRate() || threshold.IsSustained(bound.since, now, bound.samples)) {
+		bound.raised = true
+	}
+
+	return bound.raised
+}
+
+// sectorViolation builds a violation for a heading outside its sector, or not yet back within the clear
+// sector, against the nearer edge
+func sectorViolation(
+	metricName string,
+	heading float64,
+	sector, clearSector model.HeadingSector,
+	threshold *model.Threshold,
+) model.Violation {
+	pastEnd, beforeStart := sector.Outside(heading)
+	message := fmt.Sprintf("%s outside allowed sector: %.2f not in %.2f to %.2f", metricName, heading, sector.Start, sector.End)
+	if pastEnd == 0 && beforeStart == 0 {
+		// Within the sector but not yet cleared; the side is the nearer edge of the clear sector
+		pastEnd, beforeStart = clearSector.Outside(heading)
+		pastEnd, beforeStart = -pastEnd, -beforeStart
+		message = fmt.Sprintf("%s outside clear sector: %.2f not in %.2f to %.2f", metricName, heading, clearSector.Start, clearSector.End)
+	}
+
+	if math.Abs(pastEnd) <= math.Abs(beforeStart) {
+		return thresholdViolation(metricName, heading, sector.End, model.BoundTypeMax, threshold.SeverityFor(pastEnd), message)
+	}
+	return thresholdViolation(metricName, heading, sector.Start, model.BoundTypeMin, threshold.SeverityFor(beforeStart), message)
+}
+
+// thresholdViolation builds a threshold violation
+func thresholdViolation(
+	metricName string,
+	observed, limit float64,
+	bound model.BoundType,
+	severity model.Severity,
+	message string,
+) model.Violation {
+	return model.Violation{
+		Kind:     model.ViolationKindThreshold,
+		Severity: severity,
+		Metric:   metricName,
+		Observed: &observed,
+		Limit:    &limit,
+		Bound:    bound,
+		Message:  message,
+	}
+}
@@ -0,0 +1,447 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+)
+
+func float(v float64) *float64 { return &v }
+
+func integer(v int) *int { return &v }
+
+// telemetryAt builds a telemetry sample with one metric set
+func telemetryAt(at time.Time, metric model.MetricName, value float64) *model.TelemetryDTO {
+	telemetry := &model.TelemetryDTO{}
+	telemetry.Timestamp.Time = at
+	switch metric {
+	case model.MetricGroundSpeed:
+		telemetry.GroundSpeed = value
+	case model.MetricAltitude:
+		telemetry.Altitude = value
+	case model.MetricClimbRate:
+		telemetry.ClimbRate = value
+	case model.MetricHeading:
+		telemetry.Heading = value
+	}
+	return telemetry
+}
+
+// checkedSample is a telemetry sample of one metric and whether it should raise a violation
+type checkedSample struct {
+	at    time.Duration // Relative to the first sample
+	value float64
+	want  bool // Violation raised
+}
+
+// thresholdCheck is a sequence of samples checked against one threshold
+type thresholdCheck struct {
+	name      string
+	metric    model.MetricName
+	threshold model.Threshold
+	samples   []checkedSample
+}
+
+// runThresholdChecks checks each case's samples in order on a new service
+func runThresholdChecks(t *testing.T, tests []thresholdCheck) {
+	t.Helper()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			threshold := tt.threshold
+			threshold.MetricName = string(tt.metric)
+			s := NewThresholdService(memoryThresholdRepo{string(tt.metric): &threshold})
+
+			for i, sample := range tt.samples {
+				got, violations := s.CheckThresholds(1, telemetryAt(start.Add(sample.at), tt.metric, sample.value))
+				if got != sample.want {
+					t.Fatalf("sample %d (%v at %v): violation = %v, want %v (%+v)", i, sample.value, sample.at, got, sample.want, violations)
+				}
+			}
+		})
+	}
+}
+
+func TestThresholdServiceCheckThresholds(t *testing.T) {
+	runThresholdChecks(t, []thresholdCheck{
+		{
+			name:      "max",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxValue: float(100)},
+			samples:   []checkedSample{{0, 50, false}, {time.Second, 150, true}, {2 * time.Second, 90, false}},
+		},
+		{
+			name:      "heading sector through north",
+			metric:    model.MetricHeading,
+			threshold: model.Threshold{SectorStart: float(350), SectorEnd: float(10)},
+			samples: []checkedSample{
+				{0, 355, false}, {time.Second, 20, true}, {2 * time.Second, 5, false}, {3 * time.Second, 340, true},
+			},
+		},
+		{
+			name:      "heading limits read as a sector",
+			metric:    model.MetricHeading,
+			threshold: model.Threshold{MinValue: float(270), MaxValue: float(90)},
+			samples:   []checkedSample{{0, 0, false}, {time.Second, 180, true}, {2 * time.Second, 360, false}},
+		},
+		{
+			name:   "heading clear sector",
+			metric: model.MetricHeading,
+			threshold: model.Threshold{
+				SectorStart: float(0), SectorEnd: float(90), ClearMinValue: float(10), ClearMaxValue: float(80),
+			},
+			samples: []checkedSample{{0, 100, true}, {time.Second, 85, true}, {2 * time.Second, 50, false}},
+		},
+		{
+			name:      "heading rate takes the shortest turn",
+			metric:    model.MetricHeading,
+			threshold: model.Threshold{MaxRate: float(5), MinRate: float(-5)},
+			samples: []checkedSample{
+				{0, 358, false}, {time.Second, 2, false}, {2 * time.Second, 12, true}, {3 * time.Second, 2, true},
+			},
+		},
+	})
+}
+
+func TestThresholdServiceHysteresis(t *testing.T) {
+	runThresholdChecks(t, []thresholdCheck{
+		{
+			name:      "max with clear level",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxValue: float(100), ClearMaxValue: float(80)},
+			samples: []checkedSample{
+				{0, 150, true}, {time.Second, 90, true}, {2 * time.Second, 80, false}, {3 * time.Second, 90, false},
+			},
+		},
+		{
+			name:      "min with clear level",
+			metric:    model.MetricGroundSpeed,
+			threshold: model.Threshold{MinValue: float(10), ClearMinValue: float(20)},
+			samples:   []checkedSample{{0, 5, true}, {time.Second, 15, true}, {2 * time.Second, 25, false}},
+		},
+		{
+			name:      "clear level only holds a raised violation",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxValue: float(100), ClearMaxValue: float(80)},
+			samples:   []checkedSample{{0, 90, false}, {time.Second, 95, false}},
+		},
+		{
+			name:      "sustain samples",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxValue: float(100), SustainSamples: integer(3)},
+			samples: []checkedSample{
+				{0, 150, false}, {time.Second, 150, false}, {2 * time.Second, 150, true}, {3 * time.Second, 50, false},
+			},
+		},
+		{
+			name:      "sustain seconds",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxValue: float(100), SustainSeconds: integer(10)},
+			samples:   []checkedSample{{0, 150, false}, {5 * time.Second, 150, false}, {10 * time.Second, 150, true}},
+		},
+		{
+			name:      "sustain restarts after a sample within limits",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxValue: float(100), SustainSamples: integer(2)},
+			samples: []checkedSample{
+				{0, 150, false}, {time.Second, 50, false}, {2 * time.Second, 150, false}, {3 * time.Second, 150, true},
+			},
+		},
+		{
+			name:      "sustained violation holds down to the clear level",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxValue: float(100), ClearMaxValue: float(80), SustainSamples: integer(2)},
+			samples: []checkedSample{
+				{0, 150, false}, {time.Second, 150, true}, {2 * time.Second, 90, true}, {3 * time.Second, 70, false},
+			},
+		},
+		{
+			name:      "redelivered sample is not another consecutive sample",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxValue: float(100), SustainSamples: integer(2)},
+			samples:   []checkedSample{{0, 150, false}, {0, 150, false}, {time.Second, 150, true}},
+		},
+		{
+			name:      "late sample is not another consecutive sample",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxValue: float(100), SustainSamples: integer(2)},
+			samples:   []checkedSample{{time.Second, 150, false}, {0, 150, false}, {2 * time.Second, 150, true}},
+		},
+		{
+			name:      "gap in telemetry restarts sustain",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxValue: float(100), SustainSamples: integer(2)},
+			samples: []checkedSample{
+				{0, 150, false}, {10 * time.Minute, 150, false}, {10*time.Minute + time.Second, 150, true},
+			},
+		},
+	})
+}
+
+func TestThresholdServiceRates(t *testing.T) {
+	runThresholdChecks(t, []thresholdCheck{
+		{
+			name:      "max rate",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxRate: float(10)},
+			samples: []checkedSample{
+				{0, 1000, false}, {time.Second, 1005, false}, {2 * time.Second, 1030, true}, {3 * time.Second, 1035, false},
+			},
+		},
+		{
+			name:      "min rate",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MinRate: float(-10)},
+			samples:   []checkedSample{{0, 1000, false}, {2 * time.Second, 990, false}, {3 * time.Second, 970, true}},
+		},
+		{
+			name:      "rate is raised without sustain",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxRate: float(10), SustainSamples: integer(3)},
+			samples:   []checkedSample{{0, 0, false}, {time.Second, 100, true}},
+		},
+		{
+			name:      "first sample has no rate",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxRate: float(10), MinRate: float(-10)},
+			samples:   []checkedSample{{0, 5000, false}},
+		},
+		{
+			name:      "rate is per second",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxRate: float(10)},
+			samples:   []checkedSample{{0, 1000, false}, {10 * time.Second, 1090, false}, {20 * time.Second, 1200, true}},
+		},
+		{
+			name:      "late sample has no rate",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxRate: float(10), MinRate: float(-10)},
+			samples:   []checkedSample{{0, 1000, false}, {2 * time.Second, 1010, false}, {time.Second, 2000, false}, {3 * time.Second, 1015, false}},
+		},
+		{
+			name:      "no rate across a gap in telemetry",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxRate: float(10)},
+			samples:   []checkedSample{{0, 1000, false}, {10 * time.Minute, 9000, false}, {10*time.Minute + time.Second, 9005, false}},
+		},
+	})
+}
+
+func TestThresholdServiceRateViolations(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		threshold model.Threshold
+		values    []float64 // Altitudes one second apart; violations of the last are checked
+		want      []model.Violation
+	}{
+		{
+			name: "value and rate graded with their own bands",
+			threshold: model.Threshold{
+				MaxValue: float(100), WarningBand: float(10), CriticalBand: float(50),
+				MaxRate: float(10), RateWarningBand: float(5), RateCriticalBand: float(20),
+			},
+			values: []float64{100, 130},
+			want: []model.Violation{
+				{Bound: model.BoundTypeMax, Limit: float(100), Observed: float(130), Severity: model.SeverityWarning},
+				{Bound: model.BoundTypeMaxRate, Limit: float(10), Observed: float(30), Severity: model.SeverityCritical},
+			},
+		},
+		{
+			name:      "descent short of the rate warning band is info",
+			threshold: model.Threshold{MinRate: float(-10), RateWarningBand: float(5)},
+			values:    []float64{1000, 988},
+			want:      []model.Violation{{Bound: model.BoundTypeMinRate, Limit: float(-10), Observed: float(-12), Severity: model.SeverityInfo}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			threshold := tt.threshold
+			threshold.MetricName = string(model.MetricAltitude)
+			s := NewThresholdService(memoryThresholdRepo{threshold.MetricName: &threshold})
+
+			var violations []model.Violation
+			for i, value := range tt.values {
+				_, violations = s.CheckThresholds(1, telemetryAt(start.Add(time.Duration(i)*time.Second), model.MetricAltitude, value))
+			}
+
+			if len(violations) != len(tt.want) {
+				t.Fatalf("violations = %+v, want %d", violations, len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := violations[i]
+				if got.Bound != want.Bound || *got.Limit != *want.Limit || *got.Observed != *want.Observed || got.Severity != want.Severity {
+					t.Errorf("violation %d = %s %v/%v %s, want %s %v/%v %s", i,
+						got.Bound, *got.Observed, *got.Limit, got.Severity,
+						want.Bound, *want.Observed, *want.Limit, want.Severity)
+				}
+			}
+		})
+	}
+}
+
+// Telemetry is checked before its row is saved, so an entry whose save failed is checked again when it is
+// redelivered, usually after later samples
+func TestThresholdServiceRedelivery(t *testing.T) {
+	runThresholdChecks(t, []thresholdCheck{
+		{
+			name:      "redelivered latest sample keeps its rate violation",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxRate: float(10)},
+			samples: []checkedSample{
+				{0, 1000, false}, {time.Second, 1030, true}, {time.Second, 1030, true}, {2 * time.Second, 1035, false},
+			},
+		},
+		{
+			name:      "redelivered older sample keeps its rate violation",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxRate: float(10)},
+			samples: []checkedSample{
+				{0, 1000, false}, {time.Second, 1030, true}, {2 * time.Second, 1035, false},
+				{time.Second, 1030, true}, {3 * time.Second, 1040, false},
+			},
+		},
+		{
+			name:      "redelivered sample isn't counted again",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxValue: float(100), SustainSamples: integer(3)},
+			samples: []checkedSample{
+				{0, 150, false}, {time.Second, 150, false}, {0, 150, false}, {time.Second, 150, false}, {2 * time.Second, 150, true},
+			},
+		},
+		{
+			name:      "redelivered raised sample after the violation cleared",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxValue: float(100), SustainSamples: integer(2)},
+			samples: []checkedSample{
+				{0, 150, false}, {time.Second, 150, true}, {2 * time.Second, 50, false},
+				{time.Second, 150, true}, {3 * time.Second, 150, false}, {4 * time.Second, 150, true},
+			},
+		},
+		{
+			name:      "other sample at the same time is checked",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MaxValue: float(100)},
+			samples:   []checkedSample{{0, 50, false}, {time.Second, 50, false}, {time.Second, 150, true}},
+		},
+	})
+}
+
+// Results are kept for checkedSampleRetention behind the latest sample
+func TestThresholdServiceForgetsOldResults(t *testing.T) {
+	s := NewThresholdService(memoryThresholdRepo{string(model.MetricAltitude): &model.Threshold{
+		MetricName: string(model.MetricAltitude), MaxValue: float(100),
+	}}).(*thresholdService)
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for at := time.Duration(0); at <= checkedSampleRetention+2*time.Minute; at += time.Minute {
+		s.CheckThresholds(1, telemetryAt(start.Add(at), model.MetricAltitude, 50))
+	}
+
+	state := s.states[1]
+	if len(state.checked) != len(state.checkedOrder) {
+		t.Fatalf("%d results for %d keys", len(state.checked), len(state.checkedOrder))
+	}
+	if want := int(checkedSampleRetention/time.Minute) + 1; len(state.checked) != want {
+		t.Errorf("kept %d results, want %d", len(state.checked), want)
+	}
+}
+
+func TestThresholdServiceViolations(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		metric    model.MetricName
+		threshold model.Threshold
+		values    []float64 // One second apart; violations of the last are checked
+		want      []model.Violation
+	}{
+		{
+			name:      "short of the warning band is info",
+			metric:    model.MetricAltitude,
+			threshold: model.Threshold{MinValue: float(100), WarningBand: float(10)},
+			values:    []float64{95},
+			want:      []model.Violation{{Bound: model.BoundTypeMin, Limit: float(100), Observed: float(95), Severity: model.SeverityInfo}},
+		},
+		{
+			name:      "heading past the sector end",
+			metric:    model.MetricHeading,
+			threshold: model.Threshold{SectorStart: float(0), SectorEnd: float(90)},
+			values:    []float64{100},
+			want:      []model.Violation{{Bound: model.BoundTypeMax, Limit: float(90), Observed: float(100), Severity: model.SeverityWarning}},
+		},
+		{
+			name:      "heading before the sector start",
+			metric:    model.MetricHeading,
+			threshold: model.Threshold{SectorStart: float(0), SectorEnd: float(90)},
+			values:    []float64{-10},
+			want:      []model.Violation{{Bound: model.BoundTypeMin, Limit: float(0), Observed: float(350), Severity: model.SeverityWarning}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			threshold := tt.threshold
+			threshold.MetricName = string(tt.metric)
+			s := NewThresholdService(memoryThresholdRepo{string(tt.metric): &threshold})
+
+			var violations []model.Violation
+			for i, value := range tt.values {
+				_, violations = s.CheckThresholds(1, telemetryAt(start.Add(time.Duration(i)*time.Second), tt.metric, value))
+			}
+
+			if len(violations) != len(tt.want) {
+				t.Fatalf("violations = %+v, want %d", violations, len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := violations[i]
+				if got.Kind != model.ViolationKindThreshold || got.Metric != string(tt.metric) {
+					t.Errorf("violation %d = %s %s, want %s %s", i, got.Kind, got.Metric, model.ViolationKindThreshold, tt.metric)
+				}
+				if got.Bound != want.Bound || *got.Limit != *want.Limit || *got.Observed != *want.Observed || got.Severity != want.Severity {
+					t.Errorf("violation %d = %s %v/%v %s, want %s %v/%v %s", i,
+						got.Bound, *got.Observed, *got.Limit, got.Severity,
+						want.Bound, *want.Observed, *want.Limit, want.Severity)
+				}
+			}
+		})
+	}
+}
+
+func TestThresholdServiceStateEviction(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		otherAt       time.Duration // Telemetry time of another aircraft's first sample, relative to start
+		reportedAgo   time.Duration // Wall-clock time since aircraft 1 last reported when the other aircraft does
+		wantKept      bool
+		wantSustained bool // Aircraft 1's next breaching sample completes the sustain condition
+	}{
+		{"other aircraft in step", 0, 0, true, true},
+		{"other aircraft's clock far ahead", time.Hour, 0, true, true},
+		{"other aircraft's clock far behind", -time.Hour, 0, true, true},
+		{"aircraft stopped reporting", 0, thresholdStateTTL + time.Minute, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			threshold := model.Threshold{MetricName: string(model.MetricAltitude), MaxValue: float(100), SustainSamples: integer(2)}
+			s := NewThresholdService(memoryThresholdRepo{threshold.MetricName: &threshold}).(*thresholdService)
+
+			s.CheckThresholds(1, telemetryAt(start, model.MetricAltitude, 150))
+			s.states[1].lastReported = s.states[1].lastReported.Add(-tt.reportedAgo)
+
+			// Another aircraft's first sample sweeps out aircraft that stopped reporting
+			s.CheckThresholds(2, telemetryAt(start.Add(tt.otherAt), model.MetricAltitude, 50))
+			if _, kept := s.states[1]; kept != tt.wantKept {
+				t.Fatalf("state of aircraft 1 kept = %v, want %v", kept, tt.wantKept)
+			}
+
+			if got, _ := s.CheckThresholds(1, telemetryAt(start.Add(time.Second), model.MetricAltitude, 150)); got != tt.wantSustained {
+				t.Errorf("violation after the other aircraft reported = %v, want %v", got, tt.wantSustained)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/pkg/logging"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/publisher"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/repository"
+	"go.uber.org/zap"
+)
+
+// UnknownAircraftService handles telemetry from MAC addresses without a registered aircraft
+type UnknownAircraftService interface {
+	// HandleUnknown records the sighting and, in auto-register mode, returns the newly registered aircraft.
+	// A nil aircraft means the telemetry should not be processed further.
+	HandleUnknown(ctx context.Context, macAddress string, telemetry *model.TelemetryDTO) (*model.Aircraft, error)
+}
+
+type unknownAircraftService struct {
+	unknownAircraftRepo repository.UnknownAircraftRepository
+	aircraftService     AircraftService
+	feedPublisher       publisher.FeedPublisher
+	autoRegister        bool
+	defaultOwnerID      uint
+}
+
+// NewUnknownAircraftService creates a new unknown aircraft service.
+// When autoRegister is set, unknown aircraft are registered under defaultOwnerID.
+func NewUnknownAircraftService(
+	unknownAircraftRepo repository.UnknownAircraftRepository,
+	aircraftService AircraftService,
+	feedPublisher publisher.FeedPublisher,
+	autoRegister bool,
+	defaultOwnerID uint,
+) UnknownAircraftService {
+	return &unknownAircraftService{
+		unknownAircraftRepo: unknownAircraftRepo,
+		aircraftService:     aircraftService,
+		feedPublisher:       feedPublisher,
+		autoRegister:        autoRegister,
+		defaultOwnerID:      defaultOwnerID,
+	}
+}
+
+// HandleUnknown records and publishes a sighting of an unknown aircraft
+func (s *unknownAircraftService) HandleUnknown(ctx context.Context, macAddress string, telemetry *model.TelemetryDTO) (*model.Aircraft, error) {
+	seenAt := telemetry.Timestamp.Time
+	sighting := &model.UnknownAircraftSighting{
+		MACAddress:    macAddress,
+		FirstSeen:     seenAt,
+		LastSeen:      seenAt,
+		LastLatitude:  telemetry.Latitude,
+		LastLongitude: telemetry.Longitude,
+		LastAltitude:  telemetry.Altitude,
+	}
+
+	if err := s.unknownAircraftRepo.RecordSighting(sighting); err != nil {
+		return nil, fmt.Errorf("failed to record unknown aircraft sighting: %w", err)
+	}
+
+	if err := s.feedPublisher.PublishUnknownAircraft(ctx, sighting); err != nil {
+		logging.Error("Failed to publish unknown aircraft sighting",
+			zap.Error(err),
+			zap.String("mac_address", sighting.MACAddress),
+		)
+		// Don't return error - the sighting is stored
+	}
+
+	if !s.autoRegister {
+		logging.Warn("Telemetry from unknown aircraft recorded",
+			zap.String("mac_address", sighting.MACAddress),
+			zap.Int64("message_count", sighting.MessageCount),
+		)
+		return nil, nil
+	}
+
+	aircraft, err := s.aircraftService.RegisterAircraft(macAddress, s.defaultOwnerID)
+	if errors.Is(err, ErrAircraftDeleted) {
+		// Decommissioned aircraft stay out; the sighting is kept for operators to review
+		logging.Warn("Telemetry from deleted aircraft recorded, not registering it again",
+			zap.String("mac_address", sighting.MACAddress),
+			zap.Int64("message_count", sighting.MessageCount),
+		)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logging.Info("Unknown aircraft auto-registered",
+		zap.String("mac_address", aircraft.MACAddress),
+		zap.Uint("aircraft_id", aircraft.ID),
+		zap.Uint("owner_id", aircraft.OwnerID),
+	)
+
+	return aircraft, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"gorm.io/gorm"
+)
+
+func TestUnknownAircraftServiceHandleUnknown(t *testing.T) {
+	deleted := &model.Aircraft{MACAddress: "aa:bb", Name: "decommissioned", OwnerID: 9, Status: model.AircraftStatusActive}
+	deleted.ID = 1
+	deleted.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+
+	tests := []struct {
+		name         string
+		autoRegister bool
+		existing     []*model.Aircraft
+		wantAircraft bool
+	}{
+		{"recorded only", false, nil, false},
+		{"auto-registered", true, nil, true},
+		{"deleted aircraft not restored", true, []*model.Aircraft{deleted}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aircraftRepo := &memoryAircraftRepo{aircraft: make(map[string]*model.Aircraft)}
+			for _, aircraft := range tt.existing {
+				copied := *aircraft
+				aircraftRepo.aircraft[aircraft.MACAddress] = &copied
+			}
+			sightings := &memoryUnknownAircraftRepo{sightings: make(map[string]int64)}
+			feed := &recordingPublisher{}
+			s := NewUnknownAircraftService(
+				sightings,
+				NewAircraftService(aircraftRepo, nil, "", time.Minute, time.Minute),
+				feed,
+				tt.autoRegister,
+				42,
+			)
+
+			telemetry := &model.TelemetryDTO{Latitude: 41, Longitude: 29}
+			telemetry.Timestamp.Time = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+			aircraft, err := s.HandleUnknown(context.Background(), "aa:bb", telemetry)
+			if err != nil {
+				t.Fatalf("HandleUnknown() error = %v", err)
+			}
+
+			if (aircraft != nil) != tt.wantAircraft {
+				t.Fatalf("aircraft = %+v, want registered %v", aircraft, tt.wantAircraft)
+			}
+			if aircraft != nil && (aircraft.OwnerID != 42 || aircraft.Status != model.AircraftStatusActive) {
+				t.Errorf("registered aircraft owner = %d, status = %q", aircraft.OwnerID, aircraft.Status)
+			}
+			if stored := aircraftRepo.aircraft["aa:bb"]; stored != nil && stored.DeletedAt.Valid && (stored.OwnerID != 9 || stored.Name != "decommissioned") {
+				t.Errorf("deleted aircraft changed: %+v", stored)
+			}
+			if sightings.sightings["aa:bb"] != 1 || len(feed.unknown) != 1 {
+				t.Errorf("sightings = %d, published = %d, want 1 each", sightings.sightings["aa:bb"], len(feed.unknown))
+			}
+		})
+	}
+}
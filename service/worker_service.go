@@ -2,8 +2,8 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
 
 	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/consumer"
 	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
@@ -20,27 +20,39 @@ type WorkerService interface {
 }
 
 type workerService struct {
-	streamConsumer  consumer.StreamConsumer
-	aircraftService AircraftService
-	anomalyService  AnomalyService
-	telemetryRepo   repository.TelemetryRepository
-	feedPublisher   publisher.FeedPublisher
+	streamConsumer   consumer.StreamConsumer
+	aircraftService  AircraftService
+	unknownAircraft  UnknownAircraftService
+	anomalyService   AnomalyService
+	geofenceTracker  GeofenceTracker
+	anomalyEvents    AnomalyEventService
+	telemetryBatcher TelemetryBatcher
+	feedPublisher    publisher.FeedPublisher
+	quarantineRepo   repository.QuarantineRepository
 }
 
 // NewWorkerService creates a new worker service
 func NewWorkerService(
 	streamConsumer consumer.StreamConsumer,
 	aircraftService AircraftService,
+	unknownAircraft UnknownAircraftService,
 	anomalyService AnomalyService,
-	telemetryRepo repository.TelemetryRepository,
+	geofenceTracker GeofenceTracker,
+	anomalyEvents AnomalyEventService,
+	telemetryBatcher TelemetryBatcher,
 	feedPublisher publisher.FeedPublisher,
+	quarantineRepo repository.QuarantineRepository,
 ) WorkerService {
 	return &workerService{
-		streamConsumer:  streamConsumer,
-		aircraftService: aircraftService,
-		anomalyService:  anomalyService,
-		telemetryRepo:   telemetryRepo,
-		feedPublisher:   feedPublisher,
+		streamConsumer:   streamConsumer,
+		aircraftService:  aircraftService,
+		unknownAircraft:  unknownAircraft,
+		anomalyService:   anomalyService,
+		geofenceTracker:  geofenceTracker,
+		anomalyEvents:    anomalyEvents,
+		telemetryBatcher: telemetryBatcher,
+		feedPublisher:    feedPublisher,
+		quarantineRepo:   quarantineRepo,
 	}
 }
 
@@ -48,41 +60,70 @@ func NewWorkerService(
 func (w *workerService) Start(ctx context.Context) error {
 	logging.Info("Starting worker service")
 
+	// Write telemetry in the background; entries are acknowledged once their batch commits
+	go w.telemetryBatcher.Run(ctx)
+
+	// Close out incursions of aircraft that go silent, independent of other traffic
+	go w.geofenceTracker.WatchStale(ctx)
+
 	return w.streamConsumer.Consume(ctx, func(entry *consumer.StreamEntry) error {
 		return w.processEntry(ctx, entry)
 	})
 }
 
-// Stop stops the worker service
+// Stop stops the worker service and waits for queued telemetry to be written
 func (w *workerService) Stop() error {
 	logging.Info("Stopping worker service")
+	w.telemetryBatcher.Stop()
 	return nil
 }
 
+// processedEntry is the result of processing a stream entry, tracked and published once its telemetry row is written
+type processedEntry struct {
+	aircraft  *model.Aircraft
+	reading   *model.TelemetryDTO // Telemetry as received
+	telemetry *model.Telemetry
+	anomaly   *model.Anomaly
+}
+
 // processEntry processes a single stream entry
 func (w *workerService) processEntry(ctx context.Context, entry *consumer.StreamEntry) error {
 	// Get aircraft by MAC address
 	aircraft, err := w.aircraftService.GetAircraftByMAC(entry.PlaneID)
-	if err != nil {
-		logging.Warn("Aircraft not found, skipping entry",
-			zap.String("mac_address", entry.PlaneID),
-			zap.Error(err),
-		)
-		return nil // Skip entries for unknown aircraft
+	if errors.Is(err, ErrAircraftNotFound) {
+		// Record the sighting; in auto-register mode processing continues with the new aircraft
+		aircraft, err = w.unknownAircraft.HandleUnknown(ctx, entry.PlaneID, entry.Telemetry)
+		if err != nil {
+			return fmt.Errorf("failed to handle unknown aircraft: %w", err)
+		}
+		if aircraft == nil {
+			return nil
+		}
+	} else if err != nil {
+		// Lookup failed (e.g. database unavailable); leave the entry pending so it is retried
+		return fmt.Errorf("failed to get aircraft: %w", err)
 	}
 
-	// Detect anomalies
-	anomaly := w.anomalyService.DetectAnomaly(aircraft.ID, entry.Telemetry)
+	// Unknown statuses (e.g. set directly in the database) get no status policy and are processed as active
+	if !aircraft.Status.IsValid() {
+		logging.Warn("Unknown aircraft status, processing as active",
+			zap.Uint("aircraft_id", aircraft.ID),
+			zap.String("status", string(aircraft.Status)),
+		)
+	}
 
-	// Convert timestamp
-	timestamp := time.Unix(int64(entry.Telemetry.Timestamp), 0)
-	if timestamp.IsZero() {
-		timestamp = time.Now()
+	// Retired aircraft should not be transmitting; keep their telemetry out of the main table
+	if aircraft.Status == model.AircraftStatusRetired {
+		return w.quarantine(entry, aircraft, "aircraft retired")
 	}
 
+	// Detect anomalies. This runs before the telemetry row is saved, since the row stores the result;
+	// threshold state has advanced if the save fails, so a redelivered entry gets its first result back
+	anomaly := w.anomalyService.DetectAnomaly(aircraft, entry.Telemetry)
+
 	// Create telemetry record
 	telemetry := &model.Telemetry{
-		Time:        timestamp,
+		Time:        entry.Telemetry.Timestamp.Time,
 		AircraftID:  aircraft.ID,
 		Latitude:    entry.Telemetry.Latitude,
 		Longitude:   entry.Telemetry.Longitude,
@@ -92,42 +133,135 @@ func (w *workerService) processEntry(ctx context.Context, entry *consumer.Stream
 		ClimbRate:   entry.Telemetry.ClimbRate,
 		HasAnomaly:  anomaly.HasAnomaly,
 		AnomalyType: string(anomaly.AnomalyType),
+		Violations:  anomaly.Violations,
+	}
+
+	processed := &processedEntry{
+		aircraft:  aircraft,
+		reading:   entry.Telemetry,
+		telemetry: telemetry,
+		anomaly:   anomaly,
+	}
+
+	// Queue for batched database write; the entry is acknowledged with its batch once the row is
+	// written and its post-commit work is done
+	err = w.telemetryBatcher.Add(ctx, entry, telemetry, func(commitCtx context.Context, err error) error {
+		return w.afterCommit(commitCtx, processed, err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to queue telemetry for database write: %w", err)
+	}
+
+	return consumer.ErrAckDeferred
+}
+
+// quarantine stores the entry's telemetry in the quarantine table instead of telemetry_data
+func (w *workerService) quarantine(entry *consumer.StreamEntry, aircraft *model.Aircraft, reason string) error {
+	quarantined := &model.QuarantinedTelemetry{
+		AircraftID:  aircraft.ID,
+		Time:        entry.Telemetry.Timestamp.Time,
+		Latitude:    entry.Telemetry.Latitude,
+		Longitude:   entry.Telemetry.Longitude,
+		Altitude:    entry.Telemetry.Altitude,
+		GroundSpeed: entry.Telemetry.GroundSpeed,
+		Heading:     entry.Telemetry.Heading,
+		ClimbRate:   entry.Telemetry.ClimbRate,
+		Reason:      reason,
 	}
 
-	// Save to database
-	if err := w.telemetryRepo.Create(telemetry); err != nil {
+	if err := w.quarantineRepo.Create(quarantined); err != nil {
+		return fmt.Errorf("failed to quarantine telemetry: %w", err)
+	}
+
+	logging.Warn("Telemetry quarantined",
+		zap.Uint("aircraft_id", aircraft.ID),
+		zap.String("status", string(aircraft.Status)),
+		zap.String("reason", reason),
+	)
+
+	return nil
+}
+
+// afterCommit tracks geofence incursions and anomaly incidents for a telemetry row once its batch has
+// been written, and publishes the results. Alerts are not published for aircraft in maintenance.
+// Returns the write error so the stream entry is retried if the row was not saved.
+func (w *workerService) afterCommit(ctx context.Context, processed *processedEntry, err error) error {
+	telemetry, anomaly := processed.telemetry, processed.anomaly
+
+	if err != nil {
 		logging.Error("Failed to save telemetry to database",
 			zap.Error(err),
-			zap.Uint("aircraft_id", aircraft.ID),
+			zap.Uint("aircraft_id", telemetry.AircraftID),
 			zap.String("anomaly_type", string(anomaly.AnomalyType)),
 		)
 		return fmt.Errorf("failed to save telemetry to database: %w", err)
 	}
 
+	// Tracking runs only for saved rows, so a retried entry produces and publishes its events then.
+	// Turn geofence matches into entry, exit and dwell events; if they can't be saved the entry is retried
+	geofenceEvents, err := w.geofenceTracker.Track(telemetry.AircraftID, processed.reading, anomaly.Geofences)
+	if err != nil {
+		return fmt.Errorf("failed to track geofence incursions: %w", err)
+	}
+
+	// Open, update or resolve anomaly incidents
+	anomalyEvents := w.anomalyEvents.Track(telemetry.AircraftID, telemetry.Time, anomaly)
+
 	// Publish to global feed (always)
 	if err := w.feedPublisher.PublishGlobalTelemetry(ctx, telemetry); err != nil {
 		logging.Error("Failed to publish to global feed",
 			zap.Error(err),
-			zap.Uint("aircraft_id", aircraft.ID),
+			zap.Uint("aircraft_id", telemetry.AircraftID),
 			zap.String("anomaly_type", string(anomaly.AnomalyType)),
 		)
 		// Don't return error - continue processing
 	}
 
-	// Publish to alert feed if anomaly detected
-	if anomaly.HasAnomaly {
+	// Publish to alert feed if a threshold anomaly is detected.
+	// Geofence-only anomalies are reported through their incursion events, and approach-only ones through
+	// their incidents, instead of on every point.
+	// Aircraft in maintenance are expected to produce odd readings, so their alerts are suppressed.
+	suppressAlerts := processed.aircraft.Status == model.AircraftStatusMaintenance
+	alertType := anomaly.AnomalyType != model.AnomalyTypeGeofence && anomaly.AnomalyType != model.AnomalyTypeApproachingGeofence
+	if !suppressAlerts && anomaly.HasAnomaly && alertType {
 		if err := w.feedPublisher.PublishAlert(ctx, telemetry, anomaly); err != nil {
 			logging.Error("Failed to publish alert",
 				zap.Error(err),
-				zap.Uint("aircraft_id", aircraft.ID),
+				zap.Uint("aircraft_id", telemetry.AircraftID),
 				zap.String("anomaly_type", string(anomaly.AnomalyType)),
 			)
 			// Don't return error - continue processing
 		}
 	}
 
+	// Geofence and incident events are still persisted for aircraft in maintenance, just not published
+	if !suppressAlerts {
+		for _, event := range geofenceEvents {
+			if err := w.feedPublisher.PublishGeofenceEvent(ctx, event); err != nil {
+				logging.Error("Failed to publish geofence event",
+					zap.Error(err),
+					zap.Uint("aircraft_id", event.AircraftID),
+					zap.Uint("geofence_id", event.GeofenceID),
+					zap.String("event_type", string(event.EventType)),
+				)
+				// Don't return error - continue processing
+			}
+		}
+
+		for _, event := range anomalyEvents {
+			if err := w.feedPublisher.PublishAnomalyEvent(ctx, event); err != nil {
+				logging.Error("Failed to publish anomaly event",
+					zap.Error(err),
+					zap.Uint("anomaly_event_id", event.ID),
+					zap.String("status", string(event.Status)),
+				)
+				// Don't return error - continue processing
+			}
+		}
+	}
+
 	logging.Debug("Processed telemetry entry",
-		zap.Uint("aircraft_id", aircraft.ID),
+		zap.Uint("aircraft_id", telemetry.AircraftID),
 		zap.Bool("has_anomaly", anomaly.HasAnomaly),
 		zap.String("anomaly_type", string(anomaly.AnomalyType)),
 	)
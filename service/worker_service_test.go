@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/consumer"
+	"github.com/yigithankarabulut/vadi-hackathon-heisenberg-service/model"
+	"gorm.io/gorm"
+)
+
+func TestWorkerServiceStatusPolicy(t *testing.T) {
+	const movementSpeed, speedLimit = 5.0, 300.0
+
+	tests := []struct {
+		name             string
+		status           model.AircraftStatus
+		groundSpeed      float64
+		inGeofence       bool
+		wantStored       bool
+		wantQuarantined  bool
+		wantAnomalyType  model.AnomalyType
+		wantAlerts       int
+		wantFenceEvents  int // Geofence events published; they are saved either way
+		wantIncidentsPub bool
+	}{
+		{"active", model.AircraftStatusActive, 100, false, true, false, "", 0, 0, false},
+		{"active over limit", model.AircraftStatusActive, 350, false, true, false, model.AnomalyTypeThreshold, 1, 0, true},
+		{"active in geofence", model.AircraftStatusActive, 100, true, true, false, model.AnomalyTypeGeofence, 0, 1, true},
+		{"maintenance over limit", model.AircraftStatusMaintenance, 350, false, true, false, model.AnomalyTypeThreshold, 0, 0, false},
+		{"maintenance in geofence", model.AircraftStatusMaintenance, 100, true, true, false, model.AnomalyTypeGeofence, 0, 0, false},
+		{"grounded stationary", model.AircraftStatusGrounded, 0, false, true, false, "", 0, 0, false},
+		{"grounded moving", model.AircraftStatusGrounded, 20, false, true, false, model.AnomalyTypeUnauthorizedMovement, 1, 0, true},
+		{"grounded moving over limit", model.AircraftStatusGrounded, 350, false, true, false, model.AnomalyTypeUnauthorizedMovement, 1, 0, true},
+		{"retired", model.AircraftStatusRetired, 350, true, false, true, "", 0, 0, false},
+		{"unknown status processed as active", "stored", 350, false, true, false, model.AnomalyTypeThreshold, 1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aircraftRepo := &memoryAircraftRepo{aircraft: map[string]*model.Aircraft{
+				"aa:aa": {Model: gorm.Model{ID: 1}, MACAddress: "aa:aa", Status: tt.status},
+			}}
+			geofences := &staticGeofenceService{}
+			if tt.inGeofence {
+				geofences.geofences = trackerGeofences(2)
+			}
+			telemetryRepo := &memoryTelemetryRepo{rows: make(map[uint]bool)}
+			geofenceEventRepo := &memoryGeofenceEventRepo{}
+			quarantineRepo := &memoryQuarantineRepo{}
+			batcher := &inlineBatcher{telemetryRepo: telemetryRepo}
+			feed := &recordingPublisher{}
+
+			aircraftService := NewAircraftService(aircraftRepo, nil, "", time.Minute, time.Minute)
+			w := NewWorkerService(
+				nil,
+				aircraftService,
+				NewUnknownAircraftService(&memoryUnknownAircraftRepo{sightings: make(map[string]int64)}, aircraftService, feed, false, 0),
+				NewAnomalyService(
+					NewThresholdService(memoryThresholdRepo{
+						string(model.MetricGroundSpeed): {MetricName: string(model.MetricGroundSpeed), MaxValue: float(speedLimit)},
+					}),
+					geofences,
+					0,
+					movementSpeed,
+				),
+				NewGeofenceTracker(geofenceEventRepo, feed, time.Hour),
+				NewAnomalyEventService(&memoryAnomalyEventRepo{}, feed, time.Hour),
+				batcher,
+				feed,
+				quarantineRepo,
+			).(*workerService)
+
+			telemetry := positionAt(time.Now())
+			telemetry.GroundSpeed = tt.groundSpeed
+			err := w.processEntry(context.Background(), &consumer.StreamEntry{ID: "1-0", PlaneID: "aa:aa", Telemetry: telemetry})
+
+			if tt.wantQuarantined {
+				if err != nil {
+					t.Fatalf("processEntry() error = %v, want nil", err)
+				}
+				if len(quarantineRepo.rows) != 1 || quarantineRepo.rows[0].AircraftID != 1 {
+					t.Errorf("quarantined %d rows, want 1 for aircraft 1", len(quarantineRepo.rows))
+				}
+			} else if !errors.Is(err, consumer.ErrAckDeferred) {
+				t.Fatalf("processEntry() error = %v, want ErrAckDeferred", err)
+			}
+
+			if telemetryRepo.rows[1] != tt.wantStored {
+				t.Errorf("telemetry stored = %v, want %v", telemetryRepo.rows[1], tt.wantStored)
+			}
+			if tt.wantStored {
+				if len(batcher.added) != 1 {
+					t.Fatalf("rows added = %d, want 1", len(batcher.added))
+				}
+				// The row records the anomaly whether or not it is published
+				if got := model.AnomalyType(batcher.added[0].AnomalyType); got != tt.wantAnomalyType {
+					t.Errorf("anomaly type = %q, want %q", got, tt.wantAnomalyType)
+				}
+				if err := batcher.afterCommitErrs[0]; err != nil {
+					t.Errorf("afterCommit() error = %v", err)
+				}
+			}
+
+			wantTelemetry := 0
+			if tt.wantStored {
+				wantTelemetry = 1
+			}
+			if feed.telemetry != wantTelemetry {
+				t.Errorf("published telemetry %d times, want %d", feed.telemetry, wantTelemetry)
+			}
+			if feed.alerts != tt.wantAlerts {
+				t.Errorf("alerts published = %d, want %d", feed.alerts, tt.wantAlerts)
+			}
+			if len(feed.geofenceEvents) != tt.wantFenceEvents {
+				t.Errorf("geofence events published = %d, want %d", len(feed.geofenceEvents), tt.wantFenceEvents)
+			}
+			if tt.inGeofence && tt.wantStored && len(geofenceEventRepo.saved) != 1 {
+				t.Errorf("geofence events saved = %d, want 1", len(geofenceEventRepo.saved))
+			}
+			if published := len(feed.anomalyEvents) > 0; published != tt.wantIncidentsPub {
+				t.Errorf("incidents published = %d, want published = %v", len(feed.anomalyEvents), tt.wantIncidentsPub)
+			}
+		})
+	}
+}
+
+// An approach is published as an incident when it starts and ends, not as an alert on every point
+func TestWorkerServicePublishesApproachesAsIncidents(t *testing.T) {
+	const speedLimit = 300.0
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	approach := []model.GeofenceApproach{{GeofenceID: 2, GeofenceName: "zone 2", TimeToEntrySeconds: 60}}
+
+	steps := []struct {
+		approaching   bool
+		groundSpeed   float64
+		wantAlerts    int // Published so far
+		wantIncidents []model.AnomalyEventStatus
+	}{
+		{true, 100, 0, []model.AnomalyEventStatus{model.AnomalyEventOpen}},
+		{true, 100, 0, nil},
+		{true, 350, 1, []model.AnomalyEventStatus{model.AnomalyEventOpen}}, // Alerted for the threshold
+		{true, 100, 1, []model.AnomalyEventStatus{model.AnomalyEventResolved}},
+		{false, 100, 1, []model.AnomalyEventStatus{model.AnomalyEventResolved}},
+	}
+
+	aircraftRepo := &memoryAircraftRepo{aircraft: map[string]*model.Aircraft{
+		"aa:aa": {Model: gorm.Model{ID: 1}, MACAddress: "aa:aa", Status: model.AircraftStatusActive},
+	}}
+	geofences := &staticGeofenceService{}
+	batcher := &inlineBatcher{telemetryRepo: &memoryTelemetryRepo{rows: make(map[uint]bool)}}
+	feed := &recordingPublisher{}
+
+	aircraftService := NewAircraftService(aircraftRepo, nil, "", time.Minute, time.Minute)
+	w := NewWorkerService(
+		nil,
+		aircraftService,
+		NewUnknownAircraftService(&memoryUnknownAircraftRepo{sightings: make(map[string]int64)}, aircraftService, feed, false, 0),
+		NewAnomalyService(
+			NewThresholdService(memoryThresholdRepo{
+				string(model.MetricGroundSpeed): {MetricName: string(model.MetricGroundSpeed), MaxValue: float(speedLimit)},
+			}),
+			geofences,
+			time.Minute,
+			5,
+		),
+		NewGeofenceTracker(&memoryGeofenceEventRepo{}, feed, time.Hour),
+		NewAnomalyEventService(&memoryAnomalyEventRepo{}, feed, time.Hour),
+		batcher,
+		feed,
+		&memoryQuarantineRepo{},
+	).(*workerService)
+
+	for i, step := range steps {
+		geofences.approaches = nil
+		if step.approaching {
+			geofences.approaches = approach
+		}
+		published := len(feed.anomalyEvents)
+
+		telemetry := positionAt(start.Add(time.Duration(i) * time.Second))
+		telemetry.GroundSpeed = step.groundSpeed
+		err := w.processEntry(context.Background(), &consumer.StreamEntry{ID: "1-0", PlaneID: "aa:aa", Telemetry: telemetry})
+		if !errors.Is(err, consumer.ErrAckDeferred) {
+			t.Fatalf("step %d: processEntry() error = %v, want ErrAckDeferred", i, err)
+		}
+
+		if feed.alerts != step.wantAlerts {
+			t.Errorf("step %d: alerts published = %d, want %d", i, feed.alerts, step.wantAlerts)
+		}
+		var statuses []model.AnomalyEventStatus
+		for _, event := range feed.anomalyEvents[published:] {
+			statuses = append(statuses, event.Status)
+		}
+		if !equalStatuses(statuses, step.wantIncidents) {
+			t.Errorf("step %d: incidents published = %v, want %v", i, statuses, step.wantIncidents)
+		}
+	}
+}